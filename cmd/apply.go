@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/wojtekw92/pm2go/pkg/systemd"
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply <ecosystem-file>",
+	Short: "Reconcile running applications with an ecosystem file",
+	Long: `Compare the apps in an ecosystem file with the current processes and
+make them match: missing apps are created, apps whose configuration changed
+get a regenerated unit and are restarted, stopped apps are started and
+unchanged apps are left alone.
+
+With --prune, apps previously started from the same ecosystem file that are
+no longer in it are deleted. Apps started by other means are never pruned.
+
+With --dry-run the plan is followed by the unit files and systemctl
+commands it would involve, without changing anything.
+
+Examples:
+  pm2go apply ecosystem.json --dry-run      # Show the plan only
+  pm2go apply ecosystem.json                # Apply the plan
+  pm2go apply ecosystem.yml --prune --env production`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		prune, _ := cmd.Flags().GetBool("prune")
+		profile, _ := cmd.Flags().GetString("env")
+		handleApply(args[0], profile, prune)
+	},
+}
+
+func init() {
+	applyCmd.Flags().Bool("prune", false, "Delete apps from this ecosystem file that are no longer defined")
+	applyCmd.Flags().String("env", "", "Ecosystem env profile to apply (env_<profile>)")
+	addInheritEnvFlags(applyCmd)
+}
+
+// Plan actions, in the order they are printed
+const (
+	applyCreate    = "create"
+	applyUpdate    = "update"
+	applyStart     = "start"
+	applyPrune     = "prune"
+	applyUnchanged = "unchanged"
+)
+
+// applyStep is a single planned change
+type applyStep struct {
+	Action string
+	App    systemd.AppConfig
+}
+
+func handleApply(filename string, profile string, prune bool) {
+	requireValidEcosystem(filename)
+
+	steps, err := planApply(filename, profile, prune)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	printApplyPlan(filename, steps)
+
+	errorCount := 0
+	for _, step := range steps {
+		var err error
+		var done string
+		switch step.Action {
+		case applyCreate:
+			err, done = manager.Start(step.App), "Created"
+		case applyUpdate:
+			err, done = manager.Update(step.App), "Updated"
+		case applyStart:
+			err, done = manager.Restart(step.App.ID), "Started"
+		case applyPrune:
+			err, done = manager.Delete(strconv.Itoa(step.App.ID)), "Deleted"
+		default:
+			continue
+		}
+
+		if err != nil {
+			fmt.Printf("✗ Failed to %s %s: %v\n", step.Action, step.App.Name, err)
+			errorCount++
+		} else {
+			printDone("%s %s", done, step.App.Name)
+		}
+	}
+
+	if errorCount > 0 {
+		os.Exit(1)
+	}
+}
+
+// planApply compares the apps of an ecosystem file with the current processes
+func planApply(filename string, profile string, prune bool) ([]applyStep, error) {
+	apps, err := loadEcosystemApps(filename, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	processes, err := manager.List()
+	if err != nil {
+		return nil, fmt.Errorf("getting process list: %v", err)
+	}
+
+	existing := make(map[string]systemd.ProcessInfo)
+	for _, process := range processes {
+		existing[process.Name] = process
+	}
+
+	var steps []applyStep
+	desired := make(map[string]bool)
+	for _, entry := range apps {
+		for _, app := range expandInstances(entry) {
+			if desired[app.Name] {
+				return nil, fmt.Errorf("app '%s' is defined more than once in %s", app.Name, filename)
+			}
+			desired[app.Name] = true
+
+			process, found := existing[app.Name]
+			if !found {
+				steps = append(steps, applyStep{Action: applyCreate, App: app})
+				continue
+			}
+
+			app.ID = process.PM2Env.ID
+			changed, err := manager.ServiceFileChanged(app)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", app.Name, err)
+			}
+
+			switch {
+			case changed:
+				steps = append(steps, applyStep{Action: applyUpdate, App: app})
+			case process.PM2Env.Status != "online":
+				steps = append(steps, applyStep{Action: applyStart, App: app})
+			default:
+				steps = append(steps, applyStep{Action: applyUnchanged, App: app})
+			}
+		}
+	}
+
+	if prune {
+		absPath, err := filepath.Abs(filename)
+		if err != nil {
+			absPath = filename
+		}
+		for _, process := range processes {
+			if desired[process.Name] || process.PM2Env.EcosystemFile != absPath {
+				continue
+			}
+			steps = append(steps, applyStep{
+				Action: applyPrune,
+				App:    systemd.AppConfig{ID: process.PM2Env.ID, Name: process.Name},
+			})
+		}
+	}
+
+	return steps, nil
+}
+
+// printApplyPlan prints one line per app and a summary
+func printApplyPlan(filename string, steps []applyStep) {
+	symbols := map[string]string{
+		applyCreate:    "+",
+		applyUpdate:    "~",
+		applyStart:     ">",
+		applyPrune:     "-",
+		applyUnchanged: "=",
+	}
+
+	counts := make(map[string]int)
+	fmt.Printf("Plan for %s:\n", filename)
+	for _, step := range steps {
+		fmt.Printf("  %s %s (%s)\n", symbols[step.Action], step.App.Name, step.Action)
+		counts[step.Action]++
+	}
+
+	fmt.Printf("Plan: %d to create, %d to update, %d to start, %d to prune, %d unchanged\n",
+		counts[applyCreate], counts[applyUpdate], counts[applyStart], counts[applyPrune], counts[applyUnchanged])
+}
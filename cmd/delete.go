@@ -3,25 +3,38 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strconv"
 
 	"github.com/spf13/cobra"
+	"github.com/wojtekw92/pm2go/pkg/systemd"
 )
 
 var deleteCmd = &cobra.Command{
-	Use:     "delete <name>",
+	Use:     "delete <name|ecosystem-file>",
 	Aliases: []string{"del"},
 	Short:   "Delete an application",
 	Args:    cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
+		if isEcosystemArg(args[0]) {
+			only, _ := cmd.Flags().GetStringSlice("only")
+			handleEcosystemProcesses(args[0], only, "delete", "Deleted", func(process systemd.ProcessInfo) error {
+				return manager.Delete(strconv.Itoa(process.PM2Env.ID))
+			})
+			return
+		}
 		handleDelete(args[0])
 	},
 }
 
+func init() {
+	deleteCmd.Flags().StringSlice("only", []string{}, "Only delete these ecosystem apps (comma separated)")
+}
+
 func handleDelete(appName string) {
 	if err := manager.Delete(appName); err != nil {
 		fmt.Printf("Error deleting %s: %v\n", appName, err)
 		os.Exit(1)
 	}
 
-	fmt.Printf("✓ Deleted %s\n", appName)
+	printDone("Deleted %s", appName)
 }
\ No newline at end of file
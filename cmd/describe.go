@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"os/user"
 	"strconv"
 	"strings"
 	"time"
@@ -24,11 +25,16 @@ Examples:
   pm2go describe 0            # Describe process by ID`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		handleDescribe(args[0])
+		showSecrets, _ := cmd.Flags().GetBool("show-secrets")
+		handleDescribe(args[0], showSecrets)
 	},
 }
 
-func handleDescribe(identifier string) {
+func init() {
+	describeCmd.Flags().Bool("show-secrets", false, "Show secret environment values instead of masking them")
+}
+
+func handleDescribe(identifier string, showSecrets bool) {
 	var appName string
 	var targetProcess *systemd.ProcessInfo
 	
@@ -39,6 +45,12 @@ func handleDescribe(identifier string) {
 		os.Exit(1)
 	}
 	
+	// Hide secret values unless asked for
+	if err := maskProcessSecrets(processes, showSecrets); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	
 	// Try to resolve ID to app name
 	if id, err := strconv.Atoi(identifier); err == nil {
 		// It's a numeric ID
@@ -86,6 +98,7 @@ func handleDescribe(identifier string) {
 		AddKeyValue("status", targetProcess.PM2Env.Status).
 		AddKeyValue("name", targetProcess.Name).
 		AddKeyValue("namespace", "default").
+		AddKeyValue("scope", targetProcess.PM2Env.Scope).
 		AddKeyValue("version", "N/A").
 		AddKeyValue("restarts", strconv.Itoa(targetProcess.PM2Env.RestartTime)).
 		AddKeyValue("uptime", formatUptime(targetProcess.PM2Env.PMUptime)).
@@ -93,6 +106,8 @@ func handleDescribe(identifier string) {
 		AddKeyValue("script args", getScriptWithArgs(targetProcess)).
 		AddKeyValue("error log path", targetProcess.PM2Env.PMErrLogPath).
 		AddKeyValue("out log path", targetProcess.PM2Env.PMOutLogPath).
+		AddKeyValue("combined log path", getCombinedLogPath(targetProcess)).
+		AddKeyValue("log backend", targetProcess.PM2Env.LogBackend).
 		AddKeyValue("pid path", targetProcess.PM2Env.PMPidPath).
 		AddKeyValue("interpreter", getInterpreterName(targetProcess)).
 		AddKeyValue("interpreter args", "N/A").
@@ -101,6 +116,14 @@ func handleDescribe(identifier string) {
 		AddKeyValue("exec mode", "fork_mode").
 		AddKeyValue("node.js version", "N/A").
 		AddKeyValue("node env", "N/A").
+		AddKeyValue("env profile", getEnvProfile(targetProcess)).
+		AddKeyValue("ecosystem file", getEcosystemFile(targetProcess)).
+		AddKeyValue("inherit env", getInheritEnv(targetProcess)).
+		AddKeyValue("sandbox", getSandbox(targetProcess)).
+		AddKeyValue("unit template", getUnitTemplate(targetProcess)).
+		AddKeyValue("drop-ins", getDropIns(targetProcess)).
+		AddKeyValue("user", getUser(targetProcess)).
+		AddKeyValue("group", getGroup(targetProcess)).
 		AddKeyValue("watch & reload", "✘").
 		AddKeyValue("unstable restarts", strconv.Itoa(targetProcess.PM2Env.UnstableRestarts)).
 		AddKeyValue("created at", formatTimestamp(targetProcess.PM2Env.CreatedAt))
@@ -111,6 +134,13 @@ func handleDescribe(identifier string) {
 	showDivergentEnvVars(targetProcess.PM2Env.Env)
 }
 
+func getCombinedLogPath(process *systemd.ProcessInfo) string {
+	if process.PM2Env.PMLogPath != "" {
+		return process.PM2Env.PMLogPath
+	}
+	return "N/A"
+}
+
 func getInterpreter(process *systemd.ProcessInfo) string {
 	if process.PM2Env.Interpreter != "" {
 		return process.PM2Env.Interpreter
@@ -119,8 +149,8 @@ func getInterpreter(process *systemd.ProcessInfo) string {
 }
 
 func getArgs(process *systemd.ProcessInfo) string {
-	if process.PM2Env.Args != "" {
-		return process.PM2Env.Args
+	if len(process.PM2Env.Args) > 0 {
+		return systemd.JoinArgs(process.PM2Env.Args)
 	}
 	return "N/A"
 }
@@ -156,6 +186,79 @@ func getInterpreterName(process *systemd.ProcessInfo) string {
 	return interpreter
 }
 
+func getEnvProfile(process *systemd.ProcessInfo) string {
+	if process.PM2Env.EnvProfile != "" {
+		return process.PM2Env.EnvProfile
+	}
+	return "N/A"
+}
+
+func getUser(process *systemd.ProcessInfo) string {
+	if process.PM2Env.Username != "" {
+		return process.PM2Env.Username
+	}
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+	return "N/A"
+}
+
+func getGroup(process *systemd.ProcessInfo) string {
+	if process.PM2Env.Group != "" {
+		return process.PM2Env.Group
+	}
+	// Primary group of the user
+	if _, gid, err := systemd.LookupAccount(getUser(process), ""); err == nil {
+		if g, err := user.LookupGroupId(strconv.Itoa(gid)); err == nil {
+			return g.Name
+		}
+	}
+	return "N/A"
+}
+
+func getSandbox(process *systemd.ProcessInfo) string {
+	if process.PM2Env.Sandbox != "" {
+		return process.PM2Env.Sandbox
+	}
+	return systemd.SandboxNone
+}
+
+// getUnitTemplate returns the template the unit of the process is rendered from
+func getUnitTemplate(process *systemd.ProcessInfo) string {
+	if process.PM2Env.UnitTemplate != "" {
+		return process.PM2Env.UnitTemplate
+	}
+	return "built-in"
+}
+
+// getDropIns returns the systemd drop-ins applied over the unit of the process
+func getDropIns(process *systemd.ProcessInfo) string {
+	if len(process.PM2Env.DropIns) == 0 {
+		return "none"
+	}
+	return strings.Join(process.PM2Env.DropIns, ", ")
+}
+
+// getInheritEnv returns the shell environment policy the process was started with
+func getInheritEnv(process *systemd.ProcessInfo) string {
+	switch {
+	case process.PM2Env.InheritEnv != "":
+		return process.PM2Env.InheritEnv
+	case settings.InheritEnv != "":
+		return settings.InheritEnv
+	case process.PM2Env.EcosystemFile != "":
+		return systemd.InheritEnvNone
+	}
+	return systemd.InheritEnvAll
+}
+
+func getEcosystemFile(process *systemd.ProcessInfo) string {
+	if process.PM2Env.EcosystemFile != "" {
+		return process.PM2Env.EcosystemFile
+	}
+	return "N/A"
+}
+
 func getCurrentWorkingDir() string {
 	if cwd, err := os.Getwd(); err == nil {
 		return cwd
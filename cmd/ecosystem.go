@@ -0,0 +1,360 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/wojtekw92/pm2go/pkg/ecosystem"
+	"github.com/wojtekw92/pm2go/pkg/systemd"
+)
+
+var ecosystemCmd = &cobra.Command{
+	Use:   "ecosystem",
+	Short: "Work with ecosystem files",
+}
+
+var ecosystemValidateCmd = &cobra.Command{
+	Use:   "validate <file>",
+	Short: "Check an ecosystem file for errors",
+	Long: `Check an ecosystem file (JSON, YAML or JavaScript) and report every issue
+with its location: unknown keys, wrong types, duplicate names or IDs, missing
+scripts, bad working directories and interpreters that are not installed.
+
+The same checks run before apps are started from an ecosystem file.
+
+Examples:
+  pm2go ecosystem validate ecosystem.json
+  pm2go ecosystem validate ecosystem.config.js`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		handleEcosystemValidate(args[0])
+	},
+}
+
+var ecosystemGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Write an ecosystem file from the current applications",
+	Long: `Write the configuration of every current application (script, interpreter,
+args, cwd, custom log paths and env) as an ecosystem file.
+
+Apps started from the command line inherited the whole shell environment.
+The variables an app inherited from the shell are left out unless --all-env
+is given; variables set with --env, env files or an ecosystem file are kept.
+
+Examples:
+  pm2go ecosystem generate                        # Print JSON to stdout
+  pm2go ecosystem generate -o ecosystem.yml       # Format from the extension
+  pm2go ecosystem generate --format yaml`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+		format, _ := cmd.Flags().GetString("format")
+		allEnv, _ := cmd.Flags().GetBool("all-env")
+		handleEcosystemGenerate(output, format, allEnv)
+	},
+}
+
+var ecosystemInitCmd = &cobra.Command{
+	Use:   "init [file]",
+	Short: "Write a commented sample ecosystem file",
+	Long: `Write a commented sample ecosystem file to start from. The format follows
+the file name: ecosystem.config.js (default) or ecosystem.yml.
+
+Examples:
+  pm2go ecosystem init
+  pm2go ecosystem init ecosystem.yml`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		filename := "ecosystem.config.js"
+		if len(args) > 0 {
+			filename = args[0]
+		}
+		force, _ := cmd.Flags().GetBool("force")
+		handleEcosystemInit(filename, force)
+	},
+}
+
+func init() {
+	ecosystemGenerateCmd.Flags().StringP("output", "o", "", "Write to a file instead of stdout")
+	ecosystemGenerateCmd.Flags().String("format", "", "Output format: json or yaml (default from --output, else json)")
+	ecosystemGenerateCmd.Flags().Bool("all-env", false, "Keep inherited shell variables")
+	ecosystemInitCmd.Flags().Bool("force", false, "Overwrite an existing file")
+
+	ecosystemCmd.AddCommand(ecosystemValidateCmd)
+	ecosystemCmd.AddCommand(ecosystemGenerateCmd)
+	ecosystemCmd.AddCommand(ecosystemInitCmd)
+}
+
+func handleEcosystemValidate(filename string) {
+	issues, err := checkEcosystemFile(filename)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(issues) == 0 {
+		fmt.Printf("✓ %s is valid\n", filename)
+		return
+	}
+
+	printEcosystemIssues(filename, issues)
+	if ecosystem.HasErrors(issues) {
+		os.Exit(1)
+	}
+}
+
+// checkEcosystemFile validates an ecosystem file, including IDs that are
+// already taken by processes with a different name
+func checkEcosystemFile(filename string) ([]ecosystem.Issue, error) {
+	if _, err := os.Stat(filename); err != nil {
+		return nil, fmt.Errorf("reading ecosystem file: %v", err)
+	}
+
+	issues, err := ecosystem.Validate(filename, ecosystemVars)
+	if err != nil {
+		return nil, fmt.Errorf("parsing ecosystem file: %v", err)
+	}
+	if ecosystem.HasErrors(issues) {
+		return issues, nil
+	}
+
+	config, err := ecosystem.Load(filename, ecosystemVars)
+	if err != nil {
+		return nil, fmt.Errorf("parsing ecosystem file: %v", err)
+	}
+
+	processes, err := manager.List()
+	if err != nil {
+		return issues, nil
+	}
+
+	for i, app := range config.Apps {
+		if app.ID == 0 {
+			continue
+		}
+		for _, process := range processes {
+			if process.PM2Env.ID == app.ID && process.Name != app.Name {
+				issues = append(issues, ecosystem.Issue{
+					Path:    fmt.Sprintf("$.apps[%d].id", i),
+					Message: fmt.Sprintf("id %d is already used by %s", app.ID, process.Name),
+				})
+			}
+		}
+	}
+
+	return issues, nil
+}
+
+// printEcosystemIssues prints validation issues with a summary line
+func printEcosystemIssues(filename string, issues []ecosystem.Issue) {
+	errorCount := 0
+	for _, issue := range issues {
+		if !issue.Warning {
+			errorCount++
+		}
+	}
+
+	fmt.Printf("%s: %d error(s), %d warning(s)\n", filename, errorCount, len(issues)-errorCount)
+	for _, issue := range issues {
+		level := "error"
+		if issue.Warning {
+			level = "warning"
+		}
+		fmt.Printf("  %-7s %s\n", level, issue)
+	}
+}
+
+// requireValidEcosystem validates an ecosystem file before its apps are
+// started, exiting when it has errors. Warnings are printed and ignored.
+func requireValidEcosystem(filename string) {
+	issues, err := checkEcosystemFile(filename)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(issues) == 0 {
+		return
+	}
+
+	printEcosystemIssues(filename, issues)
+	if ecosystem.HasErrors(issues) {
+		fmt.Println("Error: ecosystem file is invalid, nothing was started")
+		os.Exit(1)
+	}
+}
+
+// trimShellEnv drops the variables an app inherited from the shell that
+// started it, keeping the ones set for the app itself
+func trimShellEnv(app *systemd.AppConfig) {
+	for key := range app.Env {
+		if app.EnvSource(key) == systemd.EnvSourceShell {
+			delete(app.Env, key)
+		}
+	}
+}
+
+func handleEcosystemGenerate(output string, format string, allEnv bool) {
+	if format == "" {
+		format = ecosystem.FormatJSON
+		if detected := ecosystem.DetectFormat(output); detected == ecosystem.FormatYAML {
+			format = detected
+		}
+	}
+	if format != ecosystem.FormatJSON && format != ecosystem.FormatYAML {
+		fmt.Printf("Error: unsupported format '%s' (expected json or yaml)\n", format)
+		os.Exit(1)
+	}
+
+	processes, err := manager.List()
+	if err != nil {
+		fmt.Printf("Error getting process list: %v\n", err)
+		os.Exit(1)
+	}
+	sort.Slice(processes, func(i, j int) bool {
+		return processes[i].PM2Env.ID < processes[j].PM2Env.ID
+	})
+
+	config := &systemd.EcosystemConfig{Apps: []systemd.AppConfig{}}
+	for _, process := range processes {
+		app, err := manager.GetAppConfig(strconv.Itoa(process.PM2Env.ID))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s: %v\n", process.Name, err)
+			continue
+		}
+
+		if !allEnv {
+			trimShellEnv(&app)
+		}
+		if len(app.Env) == 0 {
+			app.Env = nil
+		}
+
+		config.Apps = append(config.Apps, app)
+	}
+
+	data, err := ecosystem.Marshal(config, format)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if output == "" {
+		os.Stdout.Write(data)
+		return
+	}
+
+	if err := writeOutputFile(output, data, 0644); err != nil {
+		fmt.Printf("Error writing %s: %v\n", output, err)
+		os.Exit(1)
+	}
+	printDone("Wrote %d apps to %s", len(config.Apps), output)
+}
+
+func handleEcosystemInit(filename string, force bool) {
+	var sample string
+	switch ecosystem.DetectFormat(filename) {
+	case ecosystem.FormatJS:
+		sample = sampleEcosystemJS
+	case ecosystem.FormatYAML:
+		sample = sampleEcosystemYAML
+	case ecosystem.FormatJSON:
+		fmt.Println("Error: JSON can't hold comments, use a .config.js or .yml file name (or 'pm2go ecosystem generate')")
+		os.Exit(1)
+	default:
+		fmt.Printf("Error: unsupported ecosystem file name '%s' (use ecosystem.config.js or ecosystem.yml)\n", filename)
+		os.Exit(1)
+	}
+
+	if _, err := os.Stat(filename); err == nil && !force {
+		fmt.Printf("Error: %s already exists (use --force to overwrite)\n", filename)
+		os.Exit(1)
+	}
+
+	if err := writeOutputFile(filename, []byte(sample), 0644); err != nil {
+		fmt.Printf("Error writing %s: %v\n", filename, err)
+		os.Exit(1)
+	}
+	printDone("Wrote sample ecosystem file %s", filename)
+	if !manager.DryRun() {
+		fmt.Printf("  Edit it, then run: pm2go start %s\n", filename)
+	}
+}
+
+// sampleEcosystemJS is the sample written by "pm2go ecosystem init"
+const sampleEcosystemJS = `// pm2go ecosystem file - start with: pm2go start ecosystem.config.js
+module.exports = {
+  apps: [
+    {
+      // Unique name used by pm2go commands (logs, restart, ...)
+      name: 'my-app',
+
+      // Script to run and the interpreter to run it with
+      script: 'app.js',
+      interpreter: 'node',
+
+      // Arguments passed to the script
+      args: '--port 3000',
+
+      // Working directory, relative to this file (defaults to its directory)
+      // cwd: '/var/www/my-app',
+
+      // Number of copies, named my-app-0, my-app-1, ...
+      // instances: 2,
+
+      // Log files (default ~/.pm2/logs/<name>-out.log and -error.log)
+      // out_file: './logs/out.log',
+      // error_file: './logs/error.log',
+      // log_file: './logs/combined.log',
+      // log_backend: 'journal',
+
+      // Environment variables
+      env: {
+        NODE_ENV: 'development',
+      },
+
+      // Selected with: pm2go start ecosystem.config.js --env production
+      env_production: {
+        NODE_ENV: 'production',
+      },
+    },
+  ],
+};
+`
+
+// sampleEcosystemYAML is the YAML sample written by "pm2go ecosystem init"
+const sampleEcosystemYAML = `# pm2go ecosystem file - start with: pm2go start ecosystem.yml
+apps:
+  # Unique name used by pm2go commands (logs, restart, ...)
+  - name: my-app
+
+    # Script to run and the interpreter to run it with
+    script: app.js
+    interpreter: node
+
+    # Arguments passed to the script
+    args: "--port 3000"
+
+    # Working directory, relative to this file (defaults to its directory)
+    # cwd: /var/www/my-app
+
+    # Number of copies, named my-app-0, my-app-1, ...
+    # instances: 2
+
+    # Log files (default ~/.pm2/logs/<name>-out.log and -error.log)
+    # out_file: ./logs/out.log
+    # error_file: ./logs/error.log
+    # log_file: ./logs/combined.log
+    # log_backend: journal
+
+    # Environment variables
+    env:
+      NODE_ENV: development
+
+    # Selected with: pm2go start ecosystem.yml --env production
+    env_production:
+      NODE_ENV: production
+`
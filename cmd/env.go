@@ -16,14 +16,22 @@ var envCmd = &cobra.Command{
 
 Examples:
   pm2go env my-app       # Show environment variables for process by name
-  pm2go env 0            # Show environment variables for process by ID`,
+  pm2go env 0            # Show environment variables for process by ID
+
+Each variable is tagged with its source: shell, cli (--env), ecosystem,
+env_<profile> or env_file:<path>.`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		handleEnv(args[0])
+		showSecrets, _ := cmd.Flags().GetBool("show-secrets")
+		handleEnv(args[0], showSecrets)
 	},
 }
 
-func handleEnv(identifier string) {
+func init() {
+	envCmd.Flags().Bool("show-secrets", false, "Show secret environment values instead of masking them")
+}
+
+func handleEnv(identifier string, showSecrets bool) {
 	var targetProcess *systemd.ProcessInfo
 	
 	// Get all processes
@@ -33,6 +41,12 @@ func handleEnv(identifier string) {
 		os.Exit(1)
 	}
 	
+	// Hide secret values unless asked for
+	if err := maskProcessSecrets(processes, showSecrets); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	
 	// Try to resolve ID to app name
 	if id, err := strconv.Atoi(identifier); err == nil {
 		// It's a numeric ID
@@ -87,9 +101,16 @@ func handleEnv(identifier string) {
 		}
 	}
 	
+	// Tag each variable with where it came from
+	app, err := manager.GetAppConfig(strconv.Itoa(targetProcess.PM2Env.ID))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	
 	// Display each environment variable
 	for _, key := range keys {
 		value := targetProcess.PM2Env.Env[key]
-		fmt.Printf("%s: %s\n", key, value)
+		fmt.Printf("%s: %s  [%s]\n", key, value, app.EnvSource(key))
 	}
 }
\ No newline at end of file
@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/wojtekw92/pm2go/pkg/dotenv"
+	"github.com/wojtekw92/pm2go/pkg/ecosystem"
+	"github.com/wojtekw92/pm2go/pkg/export"
+	"github.com/wojtekw92/pm2go/pkg/systemd"
+)
+
+// Export formats
+const (
+	exportSystemd   = "systemd"
+	exportCompose   = "docker-compose"
+	exportProcfile  = "procfile"
+	exportEcosystem = "ecosystem"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export <name|id|all>",
+	Short: "Export applications to run without pm2go",
+	Long: `Write standalone artifacts for applications from their stored configuration:
+
+  systemd         <name>.service units named after the apps, their drop-ins
+                  (<name>.service.d/) and <name>.env environment files
+  docker-compose  docker-compose.yml with one service per app, the app's
+                  working directory mounted at /app, and <name>.env files
+  procfile        Procfile with one process type per app and a .env file
+  ecosystem       ecosystem.json for pm2 or pm2go
+
+Environment files may hold secrets and are only readable by their owner.
+Apps started from the command line inherited the whole shell environment;
+as with 'ecosystem generate', the variables they inherited from the shell
+are left out unless --all-env is given.
+
+Examples:
+  pm2go export api --format systemd -o ./deploy
+  pm2go export all --format docker-compose -o ./compose
+  pm2go export all --format procfile -o .`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		format, _ := cmd.Flags().GetString("format")
+		output, _ := cmd.Flags().GetString("output")
+		allEnv, _ := cmd.Flags().GetBool("all-env")
+		handleExport(args[0], format, output, allEnv)
+	},
+}
+
+func init() {
+	exportCmd.Flags().String("format", exportSystemd, "Output format: systemd, docker-compose, procfile or ecosystem")
+	exportCmd.Flags().StringP("output", "o", ".", "Directory to write to")
+	exportCmd.Flags().Bool("all-env", false, "Keep inherited shell variables")
+}
+
+func handleExport(identifier, format, dir string, allEnv bool) {
+	switch format {
+	case exportSystemd, exportCompose, exportProcfile, exportEcosystem:
+	default:
+		fmt.Printf("Error: unsupported format '%s' (expected systemd, docker-compose, procfile or ecosystem)\n", format)
+		os.Exit(1)
+	}
+
+	processes, err := exportTargets(identifier)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	apps := make([]systemd.AppConfig, 0, len(processes))
+	for _, process := range processes {
+		app, err := manager.GetAppConfig(strconv.Itoa(process.PM2Env.ID))
+		if err != nil {
+			fmt.Printf("Error reading %s: %v\n", process.Name, err)
+			os.Exit(1)
+		}
+		if !allEnv {
+			trimShellEnv(&app)
+		}
+		// Exported files are used from elsewhere
+		if app.Cwd != "" {
+			app.Cwd, _ = filepath.Abs(app.Cwd)
+		}
+		apps = append(apps, app)
+	}
+
+	dir, err = filepath.Abs(dir)
+	if err == nil {
+		err = mkdirOutput(dir)
+	}
+	if err != nil {
+		fmt.Printf("Error creating %s: %v\n", dir, err)
+		os.Exit(1)
+	}
+
+	switch format {
+	case exportSystemd:
+		err = exportSystemdUnits(processes, apps, dir)
+	case exportCompose:
+		err = exportComposeFile(apps, dir)
+	case exportProcfile:
+		err = exportProcfileFile(apps, dir)
+	case exportEcosystem:
+		err = exportEcosystemFile(apps, dir)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// exportTargets returns the processes to export, in ID order
+func exportTargets(identifier string) ([]systemd.ProcessInfo, error) {
+	processes, err := manager.List()
+	if err != nil {
+		return nil, fmt.Errorf("getting process list: %v", err)
+	}
+	sort.Slice(processes, func(i, j int) bool {
+		return processes[i].PM2Env.ID < processes[j].PM2Env.ID
+	})
+
+	if identifier == "all" {
+		if len(processes) == 0 {
+			return nil, fmt.Errorf("no applications to export")
+		}
+		return processes, nil
+	}
+	for _, process := range processes {
+		if process.Name == identifier || strconv.Itoa(process.PM2Env.ID) == identifier {
+			return []systemd.ProcessInfo{process}, nil
+		}
+	}
+	return nil, fmt.Errorf("process '%s' not found", identifier)
+}
+
+// writeExportFile writes an exported file and reports it
+func writeExportFile(path string, data []byte, perm os.FileMode) error {
+	if err := writeOutputFile(path, data, perm); err != nil {
+		return fmt.Errorf("writing %s: %v", path, err)
+	}
+	// WriteFile keeps the mode of an existing file
+	if !manager.DryRun() {
+		if err := os.Chmod(path, perm); err != nil {
+			return err
+		}
+	}
+	printDone("Wrote %s", path)
+	return nil
+}
+
+// exportSystemdUnits writes a unit, its drop-ins and its environment file
+// per app
+func exportSystemdUnits(processes []systemd.ProcessInfo, apps []systemd.AppConfig, dir string) error {
+	for i, app := range apps {
+		envPath := filepath.Join(dir, export.EnvFileName(app))
+		unit, err := manager.ExportUnit(app, envPath)
+		if err != nil {
+			return fmt.Errorf("rendering %s: %v", app.Name, err)
+		}
+		if err := writeExportFile(filepath.Join(dir, app.Name+".service"), []byte(unit), 0644); err != nil {
+			return err
+		}
+		if len(app.Env) > 0 {
+			if err := writeExportFile(envPath, []byte(systemd.FormatEnvironmentFile(app.Env)), 0600); err != nil {
+				return err
+			}
+		}
+
+		for _, dropIn := range processes[i].PM2Env.DropIns {
+			data, err := os.ReadFile(dropIn)
+			if err != nil {
+				return err
+			}
+			dropInDir := filepath.Join(dir, app.Name+".service.d")
+			if err := mkdirOutput(dropInDir); err != nil {
+				return err
+			}
+			if err := writeExportFile(filepath.Join(dropInDir, filepath.Base(dropIn)), data, 0644); err != nil {
+				return err
+			}
+		}
+	}
+
+	fmt.Printf("Install a unit with: cp %s/<name>.service /etc/systemd/system/ && systemctl daemon-reload\n", dir)
+	fmt.Println("The units read their environment files from this directory.")
+	return nil
+}
+
+// exportComposeFile writes docker-compose.yml and an environment file per app
+func exportComposeFile(apps []systemd.AppConfig, dir string) error {
+	data, err := export.Compose(apps)
+	if err != nil {
+		return err
+	}
+	if err := writeExportFile(filepath.Join(dir, "docker-compose.yml"), data, 0644); err != nil {
+		return err
+	}
+	for _, app := range apps {
+		if len(app.Env) == 0 {
+			continue
+		}
+		if err := writeExportFile(filepath.Join(dir, export.EnvFileName(app)), dotenv.Format(app.Env), 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportProcfileFile writes a Procfile and the .env file its apps share
+func exportProcfileFile(apps []systemd.AppConfig, dir string) error {
+	data, shared := export.Procfile(apps, dir)
+	if err := writeExportFile(filepath.Join(dir, "Procfile"), data, 0644); err != nil {
+		return err
+	}
+	if len(shared) > 0 {
+		return writeExportFile(filepath.Join(dir, ".env"), dotenv.Format(shared), 0600)
+	}
+	return nil
+}
+
+// exportEcosystemFile writes ecosystem.json, only readable by its owner when
+// it holds env values
+func exportEcosystemFile(apps []systemd.AppConfig, dir string) error {
+	perm := os.FileMode(0644)
+	for i := range apps {
+		if len(apps[i].Env) == 0 {
+			apps[i].Env = nil
+		} else {
+			perm = 0600
+		}
+	}
+
+	data, err := ecosystem.Marshal(&systemd.EcosystemConfig{Apps: apps}, ecosystem.FormatJSON)
+	if err != nil {
+		return err
+	}
+	return writeExportFile(filepath.Join(dir, "ecosystem.json"), data, perm)
+}
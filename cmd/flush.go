@@ -10,6 +10,11 @@ import (
 var flushCmd = &cobra.Command{
 	Use:   "flush [name]",
 	Short: "Remove logs (all logs or specific app logs)",
+	Long: `Remove logs for all applications or a specific application.
+
+Log files are truncated. For apps using the journal log backend, journald
+entries can't be deleted per app, so earlier entries are hidden from
+"pm2go logs" instead.`,
 	Args:  cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		var appName string
@@ -26,8 +31,8 @@ func handleFlush(appName string) {
 		os.Exit(1)
 	}
 	if appName == "" {
-		fmt.Println("✓ Flushed all logs")
+		printDone("Flushed all logs")
 	} else {
-		fmt.Printf("✓ Flushed logs for %s\n", appName)
+		printDone("Flushed logs for %s", appName)
 	}
 }
\ No newline at end of file
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"regexp"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wojtekw92/pm2go/pkg/config"
+	"github.com/wojtekw92/pm2go/pkg/logs"
+)
+
+var forwardCmd = &cobra.Command{
+	Use:   "forward [name|id]",
+	Short: "Forward application logs to syslog, TCP or HTTP destinations",
+	Long: `Follow the logs of a specific application or all applications and ship
+them to remote destinations until interrupted.
+
+Destinations come from --to flags or the "forwarders" list in
+~/.pm2go/config.json:
+  syslog+udp://host:514   RFC 5424 syslog over UDP
+  syslog+tcp://host:601   RFC 5424 syslog over TCP
+  tcp://host:5170         newline-delimited JSON over TCP
+  http(s)://host/path     JSON array batches sent with POST
+
+Entries are buffered in memory and retried with backoff while a destination
+is unavailable. Applications started after forward began are not picked up.
+
+Forwarding runs in the foreground and stops with this command, for example
+when its terminal closes; it is not installed as a service. Lines logged
+while it isn't running are not forwarded later.
+
+Examples:
+  pm2go forward --to syslog+udp://logs.internal:514
+  pm2go forward api --to tcp://127.0.0.1:5170 --err`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var identifier string
+		if len(args) > 0 {
+			identifier = args[0]
+		}
+		handleForward(cmd, identifier)
+	},
+}
+
+func init() {
+	forwardCmd.Flags().StringSlice("to", []string{}, "Destination (syslog+udp://, syslog+tcp://, tcp:// or http(s)://), repeatable")
+	forwardCmd.Flags().Bool("out", false, "Only forward standard output")
+	forwardCmd.Flags().Bool("err", false, "Only forward error output")
+	forwardCmd.Flags().String("grep", "", "Only forward lines matching a regular expression")
+	forwardCmd.Flags().Int("batch-size", 0, "Entries per batch (default 100)")
+	forwardCmd.Flags().Int("buffer-size", 0, "Entries buffered while a destination is down (default 10000)")
+	forwardCmd.Flags().Duration("flush-interval", 0, "Max delay before a partial batch is sent (default 1s)")
+}
+
+func handleForward(cmd *cobra.Command, identifier string) {
+	appName := identifier
+	if id, err := strconv.Atoi(identifier); err == nil {
+		// It's a numeric ID
+		processes, err := manager.List()
+		if err != nil {
+			fmt.Printf("Error getting process list: %v\n", err)
+			os.Exit(1)
+		}
+
+		appName = ""
+		for _, process := range processes {
+			if process.PM2Env.ID == id {
+				appName = process.Name
+				break
+			}
+		}
+
+		if appName == "" {
+			fmt.Printf("Error: Process with ID %d not found\n", id)
+			os.Exit(1)
+		}
+	}
+
+	// Destinations from flags override the configured ones
+	destinations := settings.Forwarders
+	if specs, _ := cmd.Flags().GetStringSlice("to"); len(specs) > 0 {
+		destinations = nil
+		for _, spec := range specs {
+			destinations = append(destinations, config.ForwarderConfig{To: spec})
+		}
+	}
+
+	if len(destinations) == 0 {
+		fmt.Println("Error: No destinations configured (use --to or \"forwarders\" in ~/.pm2go/config.json)")
+		os.Exit(1)
+	}
+
+	var filter logs.Filter
+	filter.Out, _ = cmd.Flags().GetBool("out")
+	filter.Err, _ = cmd.Flags().GetBool("err")
+	if pattern, _ := cmd.Flags().GetString("grep"); pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			fmt.Printf("Error: invalid --grep pattern: %v\n", err)
+			os.Exit(1)
+		}
+		filter.Grep = re
+	}
+
+	batchSize, _ := cmd.Flags().GetInt("batch-size")
+	bufferSize, _ := cmd.Flags().GetInt("buffer-size")
+	flushInterval, _ := cmd.Flags().GetDuration("flush-interval")
+
+	var forwarders []*logs.Forwarder
+	for _, dest := range destinations {
+		sink, err := logs.ParseSink(dest.To)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		opts := logs.ForwardOptions{
+			BatchSize:  dest.BatchSize,
+			BufferSize: dest.BufferSize,
+		}
+		if dest.FlushInterval != "" {
+			interval, err := time.ParseDuration(dest.FlushInterval)
+			if err != nil {
+				fmt.Printf("Error: invalid flush_interval for %s: %v\n", dest.To, err)
+				os.Exit(1)
+			}
+			opts.FlushInterval = interval
+		}
+		if batchSize > 0 {
+			opts.BatchSize = batchSize
+		}
+		if bufferSize > 0 {
+			opts.BufferSize = bufferSize
+		}
+		if flushInterval > 0 {
+			opts.FlushInterval = flushInterval
+		}
+
+		forwarders = append(forwarders, logs.NewForwarder(sink, opts))
+		fmt.Printf("Forwarding logs to %s\n", sink.Name())
+	}
+
+	// forward runs in the foreground: stop cleanly on Ctrl-C or SIGTERM,
+	// sending what is still buffered
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		close(stop)
+	}()
+
+	var wg sync.WaitGroup
+	for _, forwarder := range forwarders {
+		wg.Add(1)
+		go func(f *logs.Forwarder) {
+			defer wg.Done()
+			f.Run(stop)
+		}(forwarder)
+	}
+
+	err := manager.FollowLogs(appName, filter, stop, func(entry logs.Entry) {
+		for _, forwarder := range forwarders {
+			forwarder.Push(entry)
+		}
+	})
+	if err != nil {
+		fmt.Printf("Error following logs: %v\n", err)
+		os.Exit(1)
+	}
+
+	wg.Wait()
+
+	for i, forwarder := range forwarders {
+		if dropped := forwarder.Dropped(); dropped > 0 {
+			fmt.Printf("Warning: dropped %d entries for %s (buffer full)\n", dropped, destinations[i].To)
+		}
+	}
+}
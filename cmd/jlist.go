@@ -12,16 +12,25 @@ var jlistCmd = &cobra.Command{
 	Use:   "jlist",
 	Short: "List all applications in JSON format (PM2 compatible)",
 	Run: func(cmd *cobra.Command, args []string) {
-		handleJList()
+		showSecrets, _ := cmd.Flags().GetBool("show-secrets")
+		handleJList(showSecrets)
 	},
 }
 
-func handleJList() {
+func init() {
+	jlistCmd.Flags().Bool("show-secrets", false, "Show secret environment values instead of masking them")
+}
+
+func handleJList(showSecrets bool) {
 	processes, err := manager.List()
 	if err != nil {
 		fmt.Printf("Error listing processes: %v\n", err)
 		os.Exit(1)
 	}
+	if err := maskProcessSecrets(processes, showSecrets); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
 	jsonOutput, err := json.MarshalIndent(processes, "", "  ")
 	if err != nil {
 		fmt.Printf("Error marshaling JSON: %v\n", err)
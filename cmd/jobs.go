@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/wojtekw92/pm2go/internal/table"
+	"github.com/wojtekw92/pm2go/pkg/systemd"
+)
+
+var jobsCmd = &cobra.Command{
+	Use:   "jobs",
+	Short: "Show the history of one-off jobs",
+	Long: `Show the jobs started with 'pm2go run', newest first, with their status and
+exit code. The last 100 jobs are kept.
+
+Examples:
+  pm2go jobs
+  pm2go jobs --limit 5`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		limit, _ := cmd.Flags().GetInt("limit")
+		handleJobs(limit)
+	},
+}
+
+func init() {
+	jobsCmd.Flags().Int("limit", 20, "Number of jobs to show (0 for all)")
+}
+
+func handleJobs(limit int) {
+	jobs, err := manager.JobHistory()
+	if err != nil {
+		fmt.Printf("Error reading job history: %v\n", err)
+		os.Exit(1)
+	}
+
+	tbl := table.NewTable("id", "name", "status", "exit", "started", "duration", "command")
+	shown := 0
+	for i := len(jobs) - 1; i >= 0 && (limit <= 0 || shown < limit); i-- {
+		job := jobs[i]
+		if job.Scope != manager.Scope() {
+			continue
+		}
+
+		exitCode := "-"
+		if job.FinishedAt != nil {
+			exitCode = strconv.Itoa(job.ExitCode)
+		}
+		tbl.AddRow(
+			systemd.JobLabel(job.ID),
+			job.Name,
+			job.Status(),
+			exitCode,
+			job.StartedAt.Local().Format("2006-01-02 15:04:05"),
+			formatUptime(job.Duration().Milliseconds()),
+			truncateString(systemd.JoinArgs(job.Command), 40),
+		)
+		shown++
+	}
+
+	if shown == 0 {
+		fmt.Println("No jobs have been run")
+		return
+	}
+	tbl.Print()
+}
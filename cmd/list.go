@@ -8,6 +8,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/wojtekw92/pm2go/internal/table"
+	"github.com/wojtekw92/pm2go/pkg/systemd"
 )
 
 var listCmd = &cobra.Command{
@@ -26,8 +27,15 @@ func handleList() {
 		os.Exit(1)
 	}
 
+	// Jobs from 'pm2go run' show up while they run
+	jobs, err := manager.RunningJobs()
+	if err != nil {
+		fmt.Printf("Error listing jobs: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Create table with headers
-	tbl := table.NewTable("id", "name", "pid", "status", "restart", "uptime", "↺", "memory", "cpu")
+	tbl := table.NewTable("id", "name", "mode", "pid", "status", "restart", "uptime", "↺", "memory", "cpu", "scope")
 
 	// Add each process as a row
 	for _, process := range processes {
@@ -37,6 +45,7 @@ func handleList() {
 		tbl.AddRow(
 			strconv.Itoa(process.PM2Env.ID),
 			process.Name,
+			process.PM2Env.ExecMode,
 			strconv.Itoa(process.PID),
 			process.PM2Env.Status,
 			strconv.Itoa(process.PM2Env.RestartTime),
@@ -44,6 +53,23 @@ func handleList() {
 			strconv.Itoa(process.PM2Env.RestartTime),
 			memory,
 			fmt.Sprintf("%d%%", process.Monit.CPU),
+			process.PM2Env.Scope,
+		)
+	}
+
+	for _, job := range jobs {
+		tbl.AddRow(
+			systemd.JobLabel(job.PM2Env.ID),
+			job.Name,
+			job.PM2Env.ExecMode,
+			strconv.Itoa(job.PID),
+			job.PM2Env.Status,
+			"-",
+			formatUptime(job.PM2Env.PMUptime),
+			"-",
+			formatMemory(job.Monit.Memory),
+			fmt.Sprintf("%d%%", job.Monit.CPU),
+			job.PM2Env.Scope,
 		)
 	}
 
@@ -3,22 +3,32 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"regexp"
 	"strconv"
 
 	"github.com/spf13/cobra"
+	"github.com/wojtekw92/pm2go/pkg/logs"
+	"github.com/wojtekw92/pm2go/pkg/systemd"
 )
 
 var logsCmd = &cobra.Command{
 	Use:   "logs [name]",
 	Short: "Show logs for applications",
 	Long: `Show logs for a specific application or all PM2go applications.
-Uses systemd's journald for log management.
+Reads the PM2-style log files, including rotated and gzip-compressed ones,
+or systemd's journald for apps started with the journal log backend.
+--out and --err need separate stdout and stderr files: the journal and
+combined log files hold both streams without telling them apart.
 
 Examples:
   pm2go logs              # Show logs for all applications
   pm2go logs my-app       # Show logs for specific application
   pm2go logs my-app -f    # Follow logs in real-time
-  pm2go logs -l 100       # Show last 100 lines`,
+  pm2go logs -l 100       # Show last 100 lines
+  pm2go logs my-app --err                 # Only show stderr
+  pm2go logs --grep 'timeout|refused'     # Only lines matching a regex
+  pm2go logs --since 1h --until 10m       # Time window (timestamped logs)
+  pm2go logs my-app --json --nostream     # JSON lines, never stream`,
 	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		var appName string
@@ -26,19 +36,70 @@ Examples:
 			appName = args[0]
 		}
 		
-		lines, _ := cmd.Flags().GetInt("lines")
-		follow, _ := cmd.Flags().GetBool("follow")
+		opts, err := parseLogOptions(cmd)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
 		
-		handleLogs(appName, lines, follow)
+		handleLogs(appName, opts)
 	},
 }
 
 func init() {
-	logsCmd.Flags().IntP("lines", "l", 50, "Number of lines to display")
+	logsCmd.Flags().IntP("lines", "l", 50, "Number of lines to display (0 for all)")
 	logsCmd.Flags().BoolP("follow", "f", false, "Follow log output (like tail -f)")
+	logsCmd.Flags().Bool("out", false, "Only show standard output")
+	logsCmd.Flags().Bool("err", false, "Only show error output")
+	logsCmd.Flags().String("grep", "", "Only show lines matching a regular expression")
+	logsCmd.Flags().String("since", "", "Only show lines after a time (e.g. 2024-01-02, \"2024-01-02 15:04:05\", 1h)")
+	logsCmd.Flags().String("until", "", "Only show lines before a time (same formats as --since)")
+	logsCmd.Flags().Bool("raw", false, "Print raw log lines without app prefix")
+	logsCmd.Flags().Bool("json", false, "Print log lines as JSON objects")
+	logsCmd.Flags().Bool("nostream", false, "Print logs and exit without streaming")
+}
+
+// parseLogOptions builds log options from the logs command flags
+func parseLogOptions(cmd *cobra.Command) (systemd.LogOptions, error) {
+	var opts systemd.LogOptions
+	
+	opts.Lines, _ = cmd.Flags().GetInt("lines")
+	opts.Follow, _ = cmd.Flags().GetBool("follow")
+	opts.Raw, _ = cmd.Flags().GetBool("raw")
+	opts.JSON, _ = cmd.Flags().GetBool("json")
+	opts.Filter.Out, _ = cmd.Flags().GetBool("out")
+	opts.Filter.Err, _ = cmd.Flags().GetBool("err")
+	
+	if nostream, _ := cmd.Flags().GetBool("nostream"); nostream {
+		opts.Follow = false
+	}
+	
+	if pattern, _ := cmd.Flags().GetString("grep"); pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return opts, fmt.Errorf("invalid --grep pattern: %v", err)
+		}
+		opts.Filter.Grep = re
+	}
+	
+	since, _ := cmd.Flags().GetString("since")
+	sinceTime, err := logs.ParseTimeSpec(since)
+	if err != nil {
+		return opts, fmt.Errorf("invalid --since: %v", err)
+	}
+	opts.Filter.Since = sinceTime
+	
+	until, _ := cmd.Flags().GetString("until")
+	untilTime, err := logs.ParseTimeSpec(until)
+	if err != nil {
+		return opts, fmt.Errorf("invalid --until: %v", err)
+	}
+	opts.Filter.Until = untilTime
+	
+	return opts, nil
 }
 
-func handleLogs(identifier string, lines int, follow bool) {
+func handleLogs(identifier string, opts systemd.LogOptions) {
 	var appName string
 	
 	if identifier != "" {
@@ -70,7 +131,7 @@ func handleLogs(identifier string, lines int, follow bool) {
 		}
 	}
 	
-	if err := manager.Logs(appName, lines, follow); err != nil {
+	if err := manager.Logs(appName, opts); err != nil {
 		fmt.Printf("Error showing logs: %v\n", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/spf13/cobra"
+	"github.com/wojtekw92/pm2go/pkg/systemd"
+)
+
+var overrideCmd = &cobra.Command{
+	Use:   "override <name|id>",
+	Short: "Edit a systemd drop-in of an application",
+	Long: `Edit a drop-in file for the unit of an application. Drop-ins live in
+<unit>.service.d/ next to the unit, are applied over the unit pm2go
+generates and are kept when it is regenerated by restart or apply.
+
+Without --set or --remove the drop-in is opened in $VISUAL or $EDITOR.
+
+Examples:
+  pm2go override my-app
+  pm2go override my-app --set service.LimitNOFILE=65536
+  pm2go override my-app --set service.ExecStart= --set "service.ExecStart=/usr/bin/app --fast"
+  pm2go override my-app --name limits --remove`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name, _ := cmd.Flags().GetString("name")
+		directives, _ := cmd.Flags().GetStringArray("set")
+		remove, _ := cmd.Flags().GetBool("remove")
+		handleOverride(args[0], name, directives, remove)
+	},
+}
+
+func init() {
+	overrideCmd.Flags().String("name", "override", "Name of the drop-in file")
+	overrideCmd.Flags().StringArray("set", []string{}, "Set a directive, e.g. service.LimitNOFILE=65536 (repeatable)")
+	overrideCmd.Flags().Bool("remove", false, "Remove the drop-in")
+}
+
+// dropInTemplate is shown when a new drop-in is edited
+const dropInTemplate = `# Directives here are applied over the unit pm2go generates for %s
+# and are kept when it is regenerated. Lines starting with # are ignored.
+#
+# [Service]
+# LimitNOFILE=65536
+`
+
+func handleOverride(identifier, name string, specs []string, remove bool) {
+	var path string
+	var err error
+
+	switch {
+	case remove && len(specs) > 0:
+		fmt.Println("Error: --set and --remove can't be used together")
+		os.Exit(1)
+	case remove:
+		path, err = manager.RemoveDropIn(identifier, name)
+		if err == nil {
+			printDone("Removed drop-in %s", path)
+		}
+	case len(specs) > 0:
+		directives := make([]systemd.Directive, 0, len(specs))
+		for _, spec := range specs {
+			directive, err := systemd.ParseDirective(spec)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			directives = append(directives, directive)
+		}
+		path, err = manager.SetDropInDirectives(identifier, name, directives)
+		if err == nil {
+			printDone("Updated drop-in %s", path)
+		}
+	default:
+		path, err = editDropIn(identifier, name)
+	}
+
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if path != "" && !manager.DryRun() {
+		fmt.Printf("Restart the app to apply it: pm2go restart %s\n", identifier)
+	}
+}
+
+// editDropIn opens a drop-in in the user's editor and installs the result.
+// It returns "" when nothing was changed.
+func editDropIn(identifier, name string) (string, error) {
+	path, err := manager.DropInPath(identifier, name)
+	if err != nil {
+		return "", err
+	}
+
+	original, err := os.ReadFile(path)
+	existed := err == nil
+	if os.IsNotExist(err) {
+		original = []byte(fmt.Sprintf(dropInTemplate, identifier))
+	} else if err != nil {
+		return "", err
+	}
+
+	// Edit a copy so an aborted edit leaves the drop-in untouched
+	tmp, err := os.CreateTemp("", "pm2go-*.conf")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(original); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	tmp.Close()
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	// The editor may carry arguments, e.g. "code --wait"
+	editCmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", tmp.Name())
+	editCmd.Stdin, editCmd.Stdout, editCmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := editCmd.Run(); err != nil {
+		return "", fmt.Errorf("editor failed: %v", err)
+	}
+
+	edited, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		return "", err
+	}
+	if string(edited) == string(original) {
+		fmt.Println("No changes made")
+		return "", nil
+	}
+
+	// The outcome follows from the content: under --dry-run nothing is
+	// written to look at afterwards
+	hasDirectives, err := systemd.CheckDropIn(string(edited))
+	if err != nil {
+		return "", err
+	}
+	if !hasDirectives && !existed {
+		fmt.Println("No directives set")
+		return "", nil
+	}
+
+	if path, err = manager.WriteDropIn(identifier, name, string(edited)); err != nil {
+		return "", err
+	}
+	if hasDirectives {
+		printDone("Wrote drop-in %s", path)
+	} else {
+		printDone("Removed empty drop-in %s", path)
+	}
+	return path, nil
+}
@@ -6,30 +6,54 @@ import (
 	"strconv"
 
 	"github.com/spf13/cobra"
+	"github.com/wojtekw92/pm2go/pkg/systemd"
 )
 
 var restartCmd = &cobra.Command{
-	Use:   "restart [name|id|all]",
+	Use:   "restart [name|id|all|ecosystem-file]",
 	Short: "Restart applications",
 	Long: `Restart a specific application by name/id or restart all applications.
 
 Examples:
   pm2go restart my-app       # Restart specific application by name
   pm2go restart 0            # Restart specific application by ID
-  pm2go restart all          # Restart all applications`,
+  pm2go restart all          # Restart all applications
+  pm2go restart api --update-env              # Reload env from the ecosystem file or shell
+  pm2go restart api --update-env --env prod   # Switch to the env_prod profile
+  pm2go restart api --inherit-env PATH,HOME   # Only keep PATH and HOME from the shell
+  pm2go restart ecosystem.json --only api     # Restart apps from an ecosystem file`,
 	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) == 0 {
 			fmt.Println("Error: Please specify an application name, ID, or 'all'")
 			os.Exit(1)
 		}
-		handleRestart(args[0])
+		updateEnv, _ := cmd.Flags().GetBool("update-env")
+		profile, _ := cmd.Flags().GetString("env")
+		if profile != "" || inheritEnvFlag != "" {
+			updateEnv = true
+		}
+		if isEcosystemArg(args[0]) {
+			only, _ := cmd.Flags().GetStringSlice("only")
+			handleEcosystemProcesses(args[0], only, "restart", "Restarted", func(process systemd.ProcessInfo) error {
+				return restartProcess(process.PM2Env.ID, updateEnv, profile)
+			})
+			return
+		}
+		handleRestart(args[0], updateEnv, profile)
 	},
 }
 
-func handleRestart(identifier string) {
+func init() {
+	restartCmd.Flags().Bool("update-env", false, "Refresh the environment before restarting")
+	restartCmd.Flags().String("env", "", "Ecosystem env profile to switch to (implies --update-env)")
+	restartCmd.Flags().StringSlice("only", []string{}, "Only restart these ecosystem apps (comma separated)")
+	addInheritEnvFlags(restartCmd)
+}
+
+func handleRestart(identifier string, updateEnv bool, profile string) {
 	if identifier == "all" {
-		handleRestartAll()
+		handleRestartAll(updateEnv, profile)
 		return
 	}
 	
@@ -84,15 +108,15 @@ func handleRestart(identifier string) {
 	}
 	
 	// Restart the specific process
-	if err := manager.Restart(targetID); err != nil {
+	if err := restartProcess(targetID, updateEnv, profile); err != nil {
 		fmt.Printf("Error restarting %s: %v\n", appName, err)
 		os.Exit(1)
 	}
 	
-	fmt.Printf("✓ Restarted %s (ID: %d)\n", appName, targetID)
+	printDone("Restarted %s (ID: %d)", appName, targetID)
 }
 
-func handleRestartAll() {
+func handleRestartAll(updateEnv bool, profile string) {
 	// Get all processes
 	processes, err := manager.List()
 	if err != nil {
@@ -111,11 +135,11 @@ func handleRestartAll() {
 	errorCount := 0
 	
 	for _, process := range processes {
-		if err := manager.Restart(process.PM2Env.ID); err != nil {
+		if err := restartProcess(process.PM2Env.ID, updateEnv, profile); err != nil {
 			fmt.Printf("✗ Failed to restart %s (ID: %d): %v\n", process.Name, process.PM2Env.ID, err)
 			errorCount++
 		} else {
-			fmt.Printf("✓ Restarted %s (ID: %d)\n", process.Name, process.PM2Env.ID)
+			printDone("Restarted %s (ID: %d)", process.Name, process.PM2Env.ID)
 			successCount++
 		}
 	}
@@ -125,4 +149,72 @@ func handleRestartAll() {
 	if errorCount > 0 {
 		os.Exit(1)
 	}
-}
\ No newline at end of file
+}
+
+// restartProcess restarts a process, regenerating its service file with a
+// refreshed environment when updateEnv is set
+func restartProcess(id int, updateEnv bool, profile string) error {
+	if !updateEnv {
+		return manager.Restart(id)
+	}
+	
+	config, err := refreshAppConfig(id, profile)
+	if err != nil {
+		return err
+	}
+	return manager.Update(config)
+}
+
+// refreshAppConfig rebuilds an app's configuration with a fresh environment.
+// Apps started from an ecosystem file re-read it and apply the requested (or
+// current) env profile; other apps take the current shell environment.
+func refreshAppConfig(id int, profile string) (systemd.AppConfig, error) {
+	current, err := manager.GetAppConfig(strconv.Itoa(id))
+	if err != nil {
+		return systemd.AppConfig{}, err
+	}
+	
+	if profile == "" {
+		profile = current.EnvProfile
+	}
+	
+	if current.Ecosystem == "" {
+		if profile != "" {
+			return systemd.AppConfig{}, fmt.Errorf("env profiles only apply to apps started from an ecosystem file")
+		}
+		// Rebuild from env files and the current shell, keeping --env values
+		refreshed := current
+		refreshed.Env = make(map[string]string)
+		refreshed.EnvSources = make(map[string]string)
+		if err := refreshed.LoadEnvFiles("", true); err != nil {
+			return systemd.AppConfig{}, err
+		}
+		for key, value := range current.Env {
+			if current.EnvSource(key) == systemd.EnvSourceCLI {
+				refreshed.SetEnv(key, value, systemd.EnvSourceCLI)
+			}
+		}
+		refreshed.InheritShellEnv(os.Environ(), inheritEnvPolicy(&refreshed, systemd.InheritEnvAll))
+		return refreshed, nil
+	}
+	
+	apps, err := loadEcosystemApps(current.Ecosystem, "")
+	if err != nil {
+		return systemd.AppConfig{}, err
+	}
+	
+	for _, entry := range apps {
+		for _, app := range expandInstances(entry) {
+			if app.Name != current.Name {
+				continue
+			}
+			if profile != "" && !app.ApplyEnvProfile(profile) {
+				return systemd.AppConfig{}, fmt.Errorf("%s has no env_%s block in %s", entry.Name, profile, current.Ecosystem)
+			}
+			app.ID = current.ID
+			return app, nil
+		}
+	}
+	
+	return systemd.AppConfig{}, fmt.Errorf("%s is no longer defined in %s", current.Name, current.Ecosystem)
+}
@@ -5,21 +5,124 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/wojtekw92/pm2go/pkg/config"
+	"github.com/wojtekw92/pm2go/pkg/dotenv"
 	"github.com/wojtekw92/pm2go/pkg/systemd"
 )
 
 var manager *systemd.Manager
 
+// settings holds the global configuration from ~/.pm2go/config.json
+var settings *config.Config
+
+// ecosystemVars holds the variables from --var-file for ${VAR} in ecosystem files
+var ecosystemVars = map[string]string{}
+
 var rootCmd = &cobra.Command{
 	Use:   "pm2go",
 	Short: "PM2 Systemd Wrapper",
 	Long:  `A PM2 reimplementation using systemd for process management.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if err := applyScopeFlags(cmd); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+			manager.SetDryRun(os.Stdout)
+		}
+		if err := systemd.ValidateInheritEnv(settings.InheritEnv); err != nil {
+			fmt.Printf("Error in %s: %v\n", config.Path(), err)
+			os.Exit(1)
+		}
+		if err := parseInheritEnvFlags(cmd); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		varFiles, _ := cmd.Flags().GetStringSlice("var-file")
+		for _, path := range varFiles {
+			vars, err := dotenv.Read(path)
+			if err != nil {
+				fmt.Printf("Error reading var file: %v\n", err)
+				os.Exit(1)
+			}
+			// Later files override earlier ones
+			for key, value := range vars {
+				ecosystemVars[key] = value
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringSlice("var-file", []string{}, "KEY=VALUE file with variables for ${VAR} in ecosystem files (repeatable)")
+	rootCmd.PersistentFlags().Bool("user", false, "Manage apps of the user service manager (default when not root)")
+	rootCmd.PersistentFlags().Bool("system", false, "Manage system-wide apps (default for root)")
+	rootCmd.PersistentFlags().String("as", "", "Manage the user apps of another user (root only)")
+	rootCmd.PersistentFlags().Bool("dry-run", false, "Print the unit files and systemctl commands instead of applying them")
+}
+
+// printDone reports a completed action, or one that was only described
+// under --dry-run
+func printDone(format string, args ...interface{}) {
+	if manager.DryRun() {
+		fmt.Printf("(dry run) "+format+"\n", args...)
+		return
+	}
+	fmt.Printf("✓ "+format+"\n", args...)
+}
+
+// writeOutputFile writes a file asked for on the command line, or names it
+// under --dry-run
+func writeOutputFile(path string, data []byte, perm os.FileMode) error {
+	if manager.DryRun() {
+		fmt.Printf("Would write %s\n", path)
+		return nil
+	}
+	return os.WriteFile(path, data, perm)
+}
+
+// mkdirOutput creates a directory for files asked for on the command line,
+// except under --dry-run
+func mkdirOutput(dir string) error {
+	if manager.DryRun() {
+		return nil
+	}
+	return os.MkdirAll(dir, 0755)
+}
+
+// applyScopeFlags selects the systemd instance from --user, --system and --as
+func applyScopeFlags(cmd *cobra.Command) error {
+	userScope, _ := cmd.Flags().GetBool("user")
+	systemScope, _ := cmd.Flags().GetBool("system")
+	asUser, _ := cmd.Flags().GetString("as")
+
+	scope := ""
+	switch {
+	case userScope && systemScope:
+		return fmt.Errorf("--user and --system can't be combined")
+	case systemScope && asUser != "":
+		return fmt.Errorf("--as manages user apps and can't be combined with --system")
+	case userScope:
+		scope = systemd.ScopeUser
+	case systemScope:
+		scope = systemd.ScopeSystem
+	}
+	return manager.SetScope(scope, asUser)
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 func Execute() {
 	manager = systemd.NewManager()
 
+	// Apply global settings from ~/.pm2go/config.json
+	var err error
+	settings, err = config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	manager.SetDefaultLogBackend(settings.LogBackend)
+
 	// Add all commands
 	rootCmd.AddCommand(startCmd)
 	rootCmd.AddCommand(stopCmd)
@@ -32,6 +135,17 @@ func Execute() {
 	rootCmd.AddCommand(logsCmd)
 	rootCmd.AddCommand(describeCmd)
 	rootCmd.AddCommand(envCmd)
+	rootCmd.AddCommand(forwardCmd)
+	rootCmd.AddCommand(applyCmd)
+	rootCmd.AddCommand(startOrRestartCmd)
+	rootCmd.AddCommand(startOrReloadCmd)
+	rootCmd.AddCommand(ecosystemCmd)
+	rootCmd.AddCommand(securityCmd)
+	rootCmd.AddCommand(overrideCmd)
+	rootCmd.AddCommand(unitCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(runCmd)
+	rootCmd.AddCommand(jobsCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
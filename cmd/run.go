@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/wojtekw92/pm2go/pkg/systemd"
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run [flags] -- <command> [args...]",
+	Short: "Run a one-off job and wait for it",
+	Long: `Run a command once as a transient systemd unit, for migrations and other
+one-off jobs. Its output is streamed to the terminal and pm2go exits with the
+job's exit code. Interrupting pm2go stops the job.
+
+No unit file is written: the job shows up in 'list' with the job mode while
+it runs and in 'jobs' afterwards.
+
+Examples:
+  pm2go run --name migrate -- python3 manage.py migrate
+  pm2go run -e DEBUG=1 --env-file .env -- ./scripts/backfill.sh`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name, _ := cmd.Flags().GetString("name")
+		envFlags, _ := cmd.Flags().GetStringSlice("env")
+		envFiles, _ := cmd.Flags().GetStringSlice("env-file")
+		user, _ := cmd.Flags().GetString("uid")
+		group, _ := cmd.Flags().GetString("gid")
+		handleRun(args, name, envFlags, envFiles, user, group)
+	},
+}
+
+func init() {
+	// Flags after the command belong to it
+	runCmd.Flags().SetInterspersed(false)
+	runCmd.Flags().StringP("name", "n", "", "Job name (default: the command name)")
+	runCmd.Flags().StringSliceP("env", "e", []string{}, "Environment variables (KEY=VALUE)")
+	runCmd.Flags().StringSlice("env-file", []string{}, "Read environment variables from a dotenv file (repeatable)")
+	runCmd.Flags().String("uid", "", "Run the job as this user (name or uid, system mode only)")
+	runCmd.Flags().String("gid", "", "Run the job with this group (name or gid, system mode only)")
+	addInheritEnvFlags(runCmd)
+}
+
+func handleRun(args []string, name string, envFlags []string, envFiles []string, user string, group string) {
+	config := systemd.AppConfig{
+		Script:   args[0],
+		Args:     args[1:],
+		User:     user,
+		Group:    group,
+		EnvFiles: envFiles,
+		Env:      make(map[string]string),
+	}
+	config.Cwd, _ = os.Getwd()
+
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(config.Script), filepath.Ext(config.Script))
+	}
+	config.Name = name
+
+	// Env files override the shell
+	if err := config.LoadEnvFiles("", true); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	for _, envVar := range envFlags {
+		key, value, ok := strings.Cut(envVar, "=")
+		if !ok {
+			fmt.Printf("Error: invalid environment variable '%s' (expected KEY=VALUE)\n", envVar)
+			os.Exit(1)
+		}
+		config.SetEnv(key, value, systemd.EnvSourceCLI)
+	}
+	config.InheritShellEnv(os.Environ(), inheritEnvPolicy(&config, systemd.InheritEnvAll))
+
+	// The job runs outside the terminal's process group, so Ctrl-C only
+	// reaches pm2go, which stops the unit and records how it ended
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	job, err := manager.RunJob(config, interrupt, os.Stdin, os.Stdout, os.Stderr)
+	if err != nil {
+		fmt.Printf("Error running %s: %v\n", name, err)
+		os.Exit(1)
+	}
+	if manager.DryRun() {
+		return
+	}
+	if job.ExitCode != 0 {
+		fmt.Fprintf(os.Stderr, "Job %s (%s) exited with code %d\n", job.Name, systemd.JobLabel(job.ID), job.ExitCode)
+		os.Exit(job.ExitCode)
+	}
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/wojtekw92/pm2go/internal/table"
+	"github.com/wojtekw92/pm2go/pkg/systemd"
+)
+
+var securityCmd = &cobra.Command{
+	Use:   "security <name|id>",
+	Short: "Show the sandboxing of an application",
+	Long: `Show the sandbox preset and hardening directives of an application,
+followed by the "systemd-analyze security" report when it is available.
+
+Examples:
+  pm2go security my-app
+  pm2go security 0 --directives   # Skip the systemd-analyze report`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		directivesOnly, _ := cmd.Flags().GetBool("directives")
+		handleSecurity(args[0], directivesOnly)
+	},
+}
+
+func init() {
+	securityCmd.Flags().Bool("directives", false, "Only list the configured directives")
+}
+
+func handleSecurity(identifier string, directivesOnly bool) {
+	app, err := manager.GetAppConfig(identifier)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	sandbox := app.Sandbox
+	if sandbox == "" {
+		sandbox = systemd.SandboxNone
+	}
+	fmt.Printf("Sandbox of %s: %s\n", app.Name, sandbox)
+
+	directives := manager.SandboxDirectives(app)
+	if len(directives) == 0 {
+		fmt.Println("No hardening directives set (use --sandbox basic or strict)")
+	} else {
+		names := make([]string, 0, len(directives))
+		for name := range directives {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		directiveTable := table.NewTable("Directive", "Value", "Source")
+		for _, name := range names {
+			source := "preset"
+			if _, ok := app.SandboxSet[name]; ok {
+				source = "override"
+			}
+			directiveTable.AddRow(name, directives[name], source)
+		}
+		directiveTable.Print()
+	}
+
+	if directivesOnly {
+		return
+	}
+
+	report, err := manager.AnalyzeSecurity(identifier)
+	if err != nil {
+		fmt.Printf("\nsystemd-analyze report unavailable: %v\n", err)
+		return
+	}
+	fmt.Println()
+	fmt.Print(report)
+}
@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,37 +8,167 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/wojtekw92/pm2go/pkg/ecosystem"
 	"github.com/wojtekw92/pm2go/pkg/systemd"
 )
 
 var startCmd = &cobra.Command{
-	Use:                "start [interpreter] -- [script] [args...] | start [script|ecosystem.json]",
+	Use:                "start [interpreter] -- [script] [args...] | start [script|ecosystem.json|Procfile]",
 	Short:              "Start an application or ecosystem",
 	Args:               cobra.MinimumNArgs(1),
 	DisableFlagParsing: false,
 	Run: func(cmd *cobra.Command, args []string) {
 		name, _ := cmd.Flags().GetString("name")
 		envVars, _ := cmd.Flags().GetStringSlice("env")
+		only, _ := cmd.Flags().GetStringSlice("only")
+		if formation, _ := cmd.Flags().GetString("formation"); formation != "" {
+			var err error
+			procfileFormation, err = ecosystem.ParseFormation(formation)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
 		
 		// Parse os.Args to properly handle "--" separator that Cobra consumes
-		rawArgs := parseRawArgs()
-		handleStart(rawArgs, name, envVars)
+		rawArgs := parseRawArgs(cmd)
+		handleStart(rawArgs, name, envVars, only, parseStartOptions(cmd))
 	},
 }
 
 func init() {
 	startCmd.Flags().StringP("name", "n", "", "Application name")
-	startCmd.Flags().StringSliceP("env", "e", []string{}, "Environment variables (KEY=VALUE)")
+	startCmd.Flags().StringSliceP("env", "e", []string{}, "Environment variables (KEY=VALUE), or an ecosystem env profile name")
+	startCmd.Flags().StringP("output", "o", "", "Standard output log file (/dev/null to disable)")
+	startCmd.Flags().String("error", "", "Error output log file (/dev/null to disable)")
+	startCmd.Flags().StringP("log", "l", "", "Combined stdout and stderr log file")
+	startCmd.Flags().Bool("merge-logs", false, "Write all instances to the same log files")
+	startCmd.Flags().IntP("instances", "i", 0, "Number of instances to start")
+	startCmd.Flags().String("log-backend", "", "Log backend: file (default) or journal")
+	startCmd.Flags().StringSlice("env-file", []string{}, "Read environment variables from a dotenv file (repeatable)")
+	startCmd.Flags().String("uid", "", "Run the app as this user (name or uid, system mode only)")
+	startCmd.Flags().String("gid", "", "Run the app with this group (name or gid, system mode only)")
+	startCmd.Flags().String("sandbox", "", "Hardening preset: none, basic or strict")
+	startCmd.Flags().StringArray("sandbox-set", []string{}, "Set or override a sandbox directive, e.g. ProtectHome=no (repeatable, empty value removes it)")
+	startCmd.Flags().String("unit-template", "", "Render the unit file from this text/template file")
+	startCmd.Flags().StringSlice("only", []string{}, "Only start these ecosystem apps (comma separated)")
+	startCmd.Flags().String("formation", "", "Procfile instance counts, e.g. web=2,worker=1")
+	addInheritEnvFlags(startCmd)
+}
+
+// procfileFormation holds the --formation instance counts for Procfile starts
+var procfileFormation map[string]int
+
+// inheritEnvFlag holds the shell environment policy selected with
+// --inherit-env or --no-inherit-env; empty when neither was given
+var inheritEnvFlag string
+
+// addInheritEnvFlags adds the shell environment inheritance flags to a command
+func addInheritEnvFlags(c *cobra.Command) {
+	c.Flags().StringSlice("inherit-env", []string{}, "Shell variables to inherit (comma separated), or all/none")
+	c.Flags().Bool("no-inherit-env", false, "Don't inherit any shell variables")
+}
+
+// parseInheritEnvFlags sets inheritEnvFlag from the flags of commands that
+// have them
+func parseInheritEnvFlags(cmd *cobra.Command) error {
+	if cmd.Flags().Lookup("inherit-env") == nil {
+		return nil
+	}
+
+	names, _ := cmd.Flags().GetStringSlice("inherit-env")
+	noInherit, _ := cmd.Flags().GetBool("no-inherit-env")
+	switch {
+	case noInherit && len(names) > 0:
+		return fmt.Errorf("--inherit-env and --no-inherit-env can't be used together")
+	case noInherit:
+		inheritEnvFlag = systemd.InheritEnvNone
+	case len(names) > 0:
+		inheritEnvFlag = strings.Join(names, ",")
+	}
+	return systemd.ValidateInheritEnv(inheritEnvFlag)
+}
+
+// inheritEnvPolicy returns the shell environment policy of an app: the
+// command line flags, then the app's own setting, then the global default.
+// The policy is recorded on the app; fallback applies when none is set,
+// except that nothing is inherited into another user's apps (--as).
+func inheritEnvPolicy(app *systemd.AppConfig, fallback string) string {
+	switch {
+	case inheritEnvFlag != "":
+		app.InheritEnv = inheritEnvFlag
+	case app.InheritEnv == "":
+		app.InheritEnv = settings.InheritEnv
+	}
+	if app.InheritEnv == "" {
+		// Root's shell says nothing about another user's environment
+		if manager.AsUser() != "" {
+			return systemd.InheritEnvNone
+		}
+		return fallback
+	}
+	return app.InheritEnv
+}
+
+// startOptions holds the start flags that map directly onto AppConfig fields
+type startOptions struct {
+	OutFile    string
+	ErrorFile  string
+	LogFile    string
+	MergeLogs  bool
+	Instances  int
+	LogBackend string
+	EnvFiles   []string
+	Sandbox    string
+	SandboxSet map[string]string
+	User       string
+	Group      string
+	Template   string
+}
+
+// parseStartOptions reads the AppConfig related flags of the start command
+func parseStartOptions(cmd *cobra.Command) startOptions {
+	var opts startOptions
+	opts.OutFile, _ = cmd.Flags().GetString("output")
+	opts.ErrorFile, _ = cmd.Flags().GetString("error")
+	opts.LogFile, _ = cmd.Flags().GetString("log")
+	opts.MergeLogs, _ = cmd.Flags().GetBool("merge-logs")
+	opts.Instances, _ = cmd.Flags().GetInt("instances")
+	opts.LogBackend, _ = cmd.Flags().GetString("log-backend")
+	opts.EnvFiles, _ = cmd.Flags().GetStringSlice("env-file")
+	opts.Sandbox, _ = cmd.Flags().GetString("sandbox")
+	opts.User, _ = cmd.Flags().GetString("uid")
+	opts.Group, _ = cmd.Flags().GetString("gid")
+	if template, _ := cmd.Flags().GetString("unit-template"); template != "" {
+		opts.Template, _ = filepath.Abs(template)
+	}
+	
+	directives, _ := cmd.Flags().GetStringArray("sandbox-set")
+	for _, directive := range directives {
+		name, value, found := strings.Cut(directive, "=")
+		if !found {
+			fmt.Printf("Error: invalid --sandbox-set '%s' (expected Directive=value)\n", directive)
+			os.Exit(1)
+		}
+		if opts.SandboxSet == nil {
+			opts.SandboxSet = make(map[string]string)
+		}
+		opts.SandboxSet[name] = value
+	}
+	return opts
 }
 
-// parseRawArgs extracts the arguments for "start" command from os.Args, preserving "--"
-func parseRawArgs() []string {
+// parseRawArgs extracts the positional arguments of a command from os.Args,
+// preserving "--". Flags are only recognised before the "--" separator; after
+// it everything belongs to the script.
+func parseRawArgs(cmd *cobra.Command) []string {
 	args := os.Args
 	startIndex := -1
 	
-	// Find "start" command
+	// Find the command name (or the alias it was called as)
 	for i, arg := range args {
-		if arg == "start" {
+		if arg == cmd.Name() || arg == cmd.CalledAs() {
 			startIndex = i
 			break
 		}
@@ -49,42 +178,141 @@ func parseRawArgs() []string {
 		return []string{}
 	}
 	
-	// Extract arguments after "start", skipping flags
+	// Extract arguments after the command, skipping flags
 	var result []string
 	for i := startIndex + 1; i < len(args); i++ {
 		arg := args[i]
-		// Skip flags and their values
-		if strings.HasPrefix(arg, "--name") || strings.HasPrefix(arg, "-n") {
-			if strings.Contains(arg, "=") {
-				// --name=value format, skip just this arg
-				continue
-			} else {
-				// --name value format, skip this arg and next
-				if i+1 < len(args) {
-					i++ // skip the value
-				}
-				continue
-			}
+		if arg == "--" {
+			// Everything from here on is passed through untouched
+			result = append(result, args[i:]...)
+			break
 		}
-		if strings.HasPrefix(arg, "--env") || strings.HasPrefix(arg, "-e") {
-			if strings.Contains(arg, "=") {
-				// --env=value format, skip just this arg
-				continue
-			} else {
-				// --env value format, skip this arg and next
-				if i+1 < len(args) {
-					i++ // skip the value
-				}
-				continue
-			}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			result = append(result, arg)
+			continue
+		}
+		
+		// Skip flags and their values
+		if flagTakesValue(cmd, arg) && !strings.Contains(arg, "=") && i+1 < len(args) {
+			i++ // skip the value
 		}
-		result = append(result, arg)
 	}
 	
 	return result
 }
 
-func handleStart(args []string, name string, envVars []string) {
+// flagTakesValue reports whether a command line flag expects a separate value
+func flagTakesValue(cmd *cobra.Command, arg string) bool {
+	var flag *pflag.Flag
+	if strings.HasPrefix(arg, "--") {
+		name := strings.SplitN(strings.TrimPrefix(arg, "--"), "=", 2)[0]
+		flag = cmd.Flags().Lookup(name)
+	} else {
+		shorthand := strings.TrimPrefix(arg, "-")
+		if len(shorthand) != 1 {
+			// Value attached to the shorthand, e.g. -nmy-app
+			return false
+		}
+		flag = cmd.Flags().ShorthandLookup(shorthand)
+	}
+	
+	if flag == nil {
+		return false
+	}
+	return flag.NoOptDefVal == ""
+}
+
+// applyStartOptions copies command line options onto an app config
+func applyStartOptions(config *systemd.AppConfig, opts startOptions) {
+	if opts.OutFile != "" {
+		config.OutFile = opts.OutFile
+	}
+	if opts.ErrorFile != "" {
+		config.ErrorFile = opts.ErrorFile
+	}
+	if opts.LogFile != "" {
+		config.LogFile = opts.LogFile
+	}
+	if opts.MergeLogs {
+		config.MergeLogs = true
+	}
+	if opts.Instances > 0 {
+		config.Instances = opts.Instances
+	}
+	if opts.LogBackend != "" {
+		config.LogBackend = opts.LogBackend
+	}
+	if opts.Sandbox != "" {
+		config.Sandbox = opts.Sandbox
+	}
+	if opts.SandboxSet != nil {
+		config.SandboxSet = opts.SandboxSet
+	}
+	if opts.User != "" {
+		config.User = opts.User
+	}
+	if opts.Group != "" {
+		config.Group = opts.Group
+	}
+	if opts.Template != "" {
+		config.Template = opts.Template
+	}
+}
+
+// expandInstances turns an app with several instances into one app per
+// instance named "<name>-<n>". Unless merge_logs is set every instance gets
+// its own log files, suffixed like the app name.
+func expandInstances(app systemd.AppConfig) []systemd.AppConfig {
+	if app.LogFile != "" && (app.OutFile != "" || app.ErrorFile != "") {
+		fmt.Printf("Warning: %s: log_file is not written when out_file or error_file is set, systemd writes each stream to one file\n", app.Name)
+	}
+	
+	if app.Instances <= 1 {
+		return []systemd.AppConfig{app}
+	}
+	
+	logDir := manager.LogDir()
+	
+	apps := make([]systemd.AppConfig, 0, app.Instances)
+	for i := 0; i < app.Instances; i++ {
+		instance := app
+		instance.Name = fmt.Sprintf("%s-%d", app.Name, i)
+		instance.Instances = 0
+		
+		if app.MergeLogs {
+			// Point every instance at the shared default files
+			if instance.OutFile == "" && instance.LogFile == "" {
+				instance.OutFile = filepath.Join(logDir, app.Name+"-out.log")
+			}
+			if instance.ErrorFile == "" && instance.LogFile == "" {
+				instance.ErrorFile = filepath.Join(logDir, app.Name+"-error.log")
+			}
+		} else {
+			instance.OutFile = instanceLogPath(app.OutFile, i)
+			instance.ErrorFile = instanceLogPath(app.ErrorFile, i)
+			instance.LogFile = instanceLogPath(app.LogFile, i)
+		}
+		apps = append(apps, instance)
+	}
+	return apps
+}
+
+// instanceLogPath suffixes a custom log path with the instance number
+func instanceLogPath(path string, instance int) string {
+	if path == "" || path == systemd.NullLogPath {
+		return path
+	}
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s-%d%s", strings.TrimSuffix(path, ext), instance, ext)
+}
+
+func handleStart(args []string, name string, envFlags []string, only []string, opts startOptions) {
+	envVars, profile, err := splitEnvFlags(envFlags)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Check if we're restarting an existing process by ID
 	if len(args) == 1 {
 		if id, err := strconv.Atoi(args[0]); err == nil {
@@ -94,14 +322,25 @@ func handleStart(args []string, name string, envVars []string) {
 		}
 		
 		// Check if it's an ecosystem file
-		if strings.HasSuffix(args[0], ".json") || strings.Contains(args[0], "ecosystem") {
-			handleEcosystemStart(args[0])
+		if ecosystem.IsEcosystemFile(args[0]) {
+			if len(opts.EnvFiles) > 0 {
+				fmt.Println("Error: --env-file can't be used with an ecosystem file, set env_file for its apps instead")
+				os.Exit(1)
+			}
+			handleEcosystemStart(args[0], profile, only)
 			return
 		}
 	}
 
+	if len(only) > 0 || len(procfileFormation) > 0 {
+		fmt.Println("Error: --only and --formation can only be used with an ecosystem file or Procfile")
+		os.Exit(1)
+	}
+
 	var config systemd.AppConfig
 	config.Env = make(map[string]string)
+	// Apps run where they are started from
+	config.Cwd, _ = os.Getwd()
 
 	// Parse arguments: either "script" or "interpreter -- script args..."
 	if len(args) == 1 {
@@ -122,7 +361,7 @@ func handleStart(args []string, name string, envVars []string) {
 			// No separator found, treat first arg as script, rest as arguments
 			config.Script = args[0]
 			if len(args) > 1 {
-				config.Args = strings.Join(args[1:], " ")
+				config.Args = args[1:]
 			}
 		} else {
 			// Separator found: interpreter -- script args...
@@ -138,42 +377,51 @@ func handleStart(args []string, name string, envVars []string) {
 			config.Interpreter = strings.Join(args[:separatorIndex], " ")
 			config.Script = args[separatorIndex+1]
 			if len(args) > separatorIndex+2 {
-				config.Args = strings.Join(args[separatorIndex+2:], " ")
+				config.Args = args[separatorIndex+2:]
 			}
 		}
 	}
 
-	// Add all environment variables (inherit from parent process)
-	for _, env := range os.Environ() {
-		parts := strings.SplitN(env, "=", 2)
-		if len(parts) == 2 {
-			config.Env[parts[0]] = parts[1]
-		}
+	// Env files override the shell
+	config.EnvFiles = opts.EnvFiles
+	if err := config.LoadEnvFiles("", true); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
 	}
 
 	// Add/override with command-line environment variables
 	for _, envVar := range envVars {
 		parts := strings.SplitN(envVar, "=", 2)
 		if len(parts) == 2 {
-			config.Env[parts[0]] = parts[1]
+			config.SetEnv(parts[0], parts[1], systemd.EnvSourceCLI)
 		} else {
 			fmt.Printf("Warning: Invalid environment variable format: %s (expected KEY=VALUE)\n", envVar)
 		}
 	}
 
+	// Inherit the rest from the parent process, everything by default
+	config.InheritShellEnv(os.Environ(), inheritEnvPolicy(&config, systemd.InheritEnvAll))
+
+	if profile != "" {
+		fmt.Printf("Warning: env profile '%s' ignored, profiles only apply to ecosystem files\n", profile)
+	}
+
 	if name == "" {
 		// Generate name from script filename
 		name = strings.TrimSuffix(filepath.Base(config.Script), filepath.Ext(config.Script))
 	}
 
 	config.Name = name
+	applyStartOptions(&config, opts)
 
-	if err := manager.Start(config); err != nil {
-		fmt.Printf("Error starting %s: %v\n", name, err)
-		os.Exit(1)
-	}
+	for _, app := range expandInstances(config) {
+		if err := manager.Start(app); err != nil {
+			fmt.Printf("Error starting %s: %v\n", app.Name, err)
+			os.Exit(1)
+		}
 
-	fmt.Printf("✓ Started %s\n", name)
+		printDone("Started %s", app.Name)
+	}
 }
 
 func handleStartRestart(id int) {
@@ -209,27 +457,198 @@ func handleStartRestart(id int) {
 		os.Exit(1)
 	}
 	
-	fmt.Printf("✓ Restarted %s (ID: %d)\n", targetProcess.Name, id)
+	printDone("Restarted %s (ID: %d)", targetProcess.Name, id)
+}
+
+func handleEcosystemStart(filename string, profile string, only []string) {
+	requireValidEcosystem(filename)
+
+	apps, err := ecosystemTargets(filename, profile, only)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, app := range apps {
+		if err := manager.Start(app); err != nil {
+			fmt.Printf("Error starting %s: %v\n", app.Name, err)
+		} else {
+			printDone("Started %s", app.Name)
+		}
+	}
 }
 
-func handleEcosystemStart(filename string) {
-	data, err := os.ReadFile(filename)
+// isEcosystemArg reports whether a command argument names an existing
+// ecosystem file rather than an app
+func isEcosystemArg(arg string) bool {
+	if !ecosystem.IsEcosystemFile(arg) {
+		return false
+	}
+	info, err := os.Stat(arg)
+	return err == nil && !info.IsDir()
+}
+
+// handleEcosystemProcesses runs action on the processes of the apps in an
+// ecosystem file, reporting each result. Apps that were never started are
+// skipped, and so are apps that aren't running when stopping.
+func handleEcosystemProcesses(filename string, only []string, verb string, done string, action func(process systemd.ProcessInfo) error) {
+	apps, err := ecosystemTargets(filename, "", only)
 	if err != nil {
-		fmt.Printf("Error reading ecosystem file: %v\n", err)
+		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	var ecosystem systemd.EcosystemConfig
-	if err := json.Unmarshal(data, &ecosystem); err != nil {
-		fmt.Printf("Error parsing ecosystem file: %v\n", err)
+	processes, err := manager.List()
+	if err != nil {
+		fmt.Printf("Error getting process list: %v\n", err)
 		os.Exit(1)
 	}
 
-	for _, app := range ecosystem.Apps {
-		if err := manager.Start(app); err != nil {
-			fmt.Printf("Error starting %s: %v\n", app.Name, err)
+	errorCount := 0
+	for _, app := range apps {
+		var process *systemd.ProcessInfo
+		for i := range processes {
+			if processes[i].Name == app.Name {
+				process = &processes[i]
+				break
+			}
+		}
+		if process == nil {
+			fmt.Printf("- %s not found, skipped\n", app.Name)
+			continue
+		}
+		if verb == "stop" && process.PM2Env.Status != "online" {
+			fmt.Printf("- %s is not running (%s), skipped\n", app.Name, process.PM2Env.Status)
+			continue
+		}
+
+		if err := action(*process); err != nil {
+			fmt.Printf("✗ Failed to %s %s (ID: %d): %v\n", verb, app.Name, process.PM2Env.ID, err)
+			errorCount++
 		} else {
-			fmt.Printf("✓ Started %s\n", app.Name)
+			printDone("%s %s (ID: %d)", done, app.Name, process.PM2Env.ID)
 		}
 	}
-}
\ No newline at end of file
+
+	if errorCount > 0 {
+		os.Exit(1)
+	}
+}
+
+// ecosystemTargets loads an ecosystem file and returns its apps expanded to
+// one entry per instance. With only set, just the listed apps (by app or
+// instance name) are returned.
+func ecosystemTargets(filename string, profile string, only []string) ([]systemd.AppConfig, error) {
+	apps, err := loadEcosystemApps(filename, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]bool)
+	for _, name := range only {
+		wanted[name] = false
+	}
+
+	var targets []systemd.AppConfig
+	for _, entry := range apps {
+		for _, app := range expandInstances(entry) {
+			if len(only) > 0 {
+				if _, ok := wanted[entry.Name]; ok {
+					wanted[entry.Name] = true
+				} else if _, ok := wanted[app.Name]; ok {
+					wanted[app.Name] = true
+				} else {
+					continue
+				}
+			}
+			targets = append(targets, app)
+		}
+	}
+
+	for _, name := range only {
+		if !wanted[name] {
+			return nil, fmt.Errorf("app '%s' is not defined in %s", name, filename)
+		}
+	}
+	return targets, nil
+}
+
+// loadEcosystemApps reads the apps of an ecosystem file, applies the env
+// profile (env_<profile>) and records where each app came from
+func loadEcosystemApps(filename string, profile string) ([]systemd.AppConfig, error) {
+	if _, err := os.Stat(filename); err != nil {
+		return nil, fmt.Errorf("reading ecosystem file: %v", err)
+	}
+
+	config, err := ecosystem.Load(filename, ecosystemVars)
+	if err != nil {
+		return nil, fmt.Errorf("parsing ecosystem file: %v", err)
+	}
+
+	if len(procfileFormation) > 0 {
+		if ecosystem.DetectFormat(filename) != ecosystem.FormatProcfile {
+			return nil, fmt.Errorf("--formation only applies to Procfiles")
+		}
+		if err := ecosystem.ApplyFormation(config, filename, procfileFormation); err != nil {
+			return nil, err
+		}
+	}
+
+	absPath, err := filepath.Abs(filename)
+	if err != nil {
+		absPath = filename
+	}
+
+	var missing []string
+	for i := range config.Apps {
+		config.Apps[i].Ecosystem = absPath
+		// Apps run in the ecosystem file's directory, or a cwd relative to
+		// it, wherever pm2go is started or applied from. The cwd is resolved
+		// here once, so plans, units and log paths agree on it.
+		if config.Apps[i].Cwd == "" {
+			config.Apps[i].Cwd = filepath.Dir(absPath)
+		} else {
+			config.Apps[i].Cwd, _ = ecosystem.ResolveCwd(config.Apps[i].Cwd, filepath.Dir(absPath))
+		}
+		// Env files are relative to the ecosystem file and don't override env
+		if err := config.Apps[i].LoadEnvFiles(filepath.Dir(absPath), false); err != nil {
+			return nil, fmt.Errorf("%s: %v", config.Apps[i].Name, err)
+		}
+		// So is the unit template
+		if template := config.Apps[i].Template; template != "" && !filepath.IsAbs(template) {
+			config.Apps[i].Template = filepath.Join(filepath.Dir(absPath), template)
+		}
+		if profile != "" && !config.Apps[i].ApplyEnvProfile(profile) {
+			missing = append(missing, config.Apps[i].Name)
+		}
+		// Ecosystem apps only inherit the shell when asked to
+		config.Apps[i].InheritShellEnv(os.Environ(), inheritEnvPolicy(&config.Apps[i], systemd.InheritEnvNone))
+	}
+
+	if profile != "" && len(missing) == len(config.Apps) {
+		return nil, fmt.Errorf("env profile '%s' is not defined in %s (expected env_%s blocks)", profile, filename, profile)
+	}
+	for _, name := range missing {
+		fmt.Printf("Warning: %s has no env_%s block, using env\n", name, profile)
+	}
+
+	return config.Apps, nil
+}
+
+// splitEnvFlags separates --env KEY=VALUE variables from a PM2-style
+// --env <profile> selection
+func splitEnvFlags(envVars []string) ([]string, string, error) {
+	var vars []string
+	var profile string
+	for _, envVar := range envVars {
+		if strings.Contains(envVar, "=") {
+			vars = append(vars, envVar)
+			continue
+		}
+		if profile != "" && profile != envVar {
+			return nil, "", fmt.Errorf("only one env profile can be selected (got '%s' and '%s')", profile, envVar)
+		}
+		profile = envVar
+	}
+	return vars, profile, nil
+}
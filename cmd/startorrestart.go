@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var startOrRestartCmd = &cobra.Command{
+	Use:   "startOrRestart <ecosystem-file>",
+	Short: "Restart running ecosystem apps and start missing ones",
+	Long: `Restart the apps of an ecosystem file that are already running and
+start the ones that are not.
+
+Examples:
+  pm2go startOrRestart ecosystem.json
+  pm2go startOrRestart ecosystem.config.js --only api,worker --update-env`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		handleStartOrRestart(cmd, args[0], false)
+	},
+}
+
+var startOrReloadCmd = &cobra.Command{
+	Use:   "startOrReload <ecosystem-file>",
+	Short: "Reload running ecosystem apps and start missing ones",
+	Long: `Reload the apps of an ecosystem file that are already running and start
+the ones that are not. Apps are reloaded with "systemctl reload-or-restart",
+so units without a reload command are restarted.
+
+Examples:
+  pm2go startOrReload ecosystem.json
+  pm2go startOrReload ecosystem.config.js --only api,worker`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		handleStartOrRestart(cmd, args[0], true)
+	},
+}
+
+func init() {
+	for _, c := range []*cobra.Command{startOrRestartCmd, startOrReloadCmd} {
+		c.Flags().StringSlice("only", []string{}, "Only act on these ecosystem apps (comma separated)")
+		c.Flags().String("env", "", "Ecosystem env profile to use (env_<profile>)")
+		c.Flags().Bool("update-env", false, "Regenerate running apps with the ecosystem configuration")
+		addInheritEnvFlags(c)
+	}
+}
+
+func handleStartOrRestart(cmd *cobra.Command, filename string, reload bool) {
+	only, _ := cmd.Flags().GetStringSlice("only")
+	profile, _ := cmd.Flags().GetString("env")
+	updateEnv, _ := cmd.Flags().GetBool("update-env")
+
+	if !isEcosystemArg(filename) {
+		fmt.Printf("Error: %s is not an ecosystem file\n", filename)
+		os.Exit(1)
+	}
+	requireValidEcosystem(filename)
+
+	apps, err := ecosystemTargets(filename, profile, only)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	processes, err := manager.List()
+	if err != nil {
+		fmt.Printf("Error getting process list: %v\n", err)
+		os.Exit(1)
+	}
+
+	running := make(map[string]int)
+	for _, process := range processes {
+		running[process.Name] = process.PM2Env.ID
+	}
+
+	errorCount := 0
+	for _, app := range apps {
+		id, found := running[app.Name]
+
+		var err error
+		var done string
+		switch {
+		case !found:
+			err, done = manager.Start(app), "Started"
+		case updateEnv || profile != "" || inheritEnvFlag != "":
+			// Rewrite the unit with the current ecosystem configuration
+			app.ID = id
+			err, done = manager.Update(app), "Updated"
+		case reload:
+			err, done = manager.Reload(id), "Reloaded"
+		default:
+			err, done = manager.Restart(id), "Restarted"
+		}
+
+		if err != nil {
+			fmt.Printf("✗ Failed to start or restart %s: %v\n", app.Name, err)
+			errorCount++
+		} else {
+			printDone("%s %s", done, app.Name)
+		}
+	}
+
+	if errorCount > 0 {
+		os.Exit(1)
+	}
+}
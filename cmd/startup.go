@@ -27,6 +27,13 @@ func handleStartup() {
 		return
 	}
 
+	if manager.DryRun() {
+		for _, command := range manager.GetStartupCommands() {
+			fmt.Printf("Would run: %s\n", command)
+		}
+		return
+	}
+
 	// Show what will be done
 	username := os.Getenv("USER")
 	uid := os.Getuid()
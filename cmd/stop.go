@@ -5,22 +5,34 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/wojtekw92/pm2go/pkg/systemd"
 )
 
 var stopCmd = &cobra.Command{
-	Use:   "stop <name>",
+	Use:   "stop <name|ecosystem-file>",
 	Short: "Stop an application",
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
+		if isEcosystemArg(args[0]) {
+			only, _ := cmd.Flags().GetStringSlice("only")
+			handleEcosystemProcesses(args[0], only, "stop", "Stopped", func(process systemd.ProcessInfo) error {
+				return manager.Stop(process.Name)
+			})
+			return
+		}
 		handleStop(args[0])
 	},
 }
 
+func init() {
+	stopCmd.Flags().StringSlice("only", []string{}, "Only stop these ecosystem apps (comma separated)")
+}
+
 func handleStop(appName string) {
 	if err := manager.Stop(appName); err != nil {
 		fmt.Printf("Error stopping %s: %v\n", appName, err)
 		os.Exit(1)
 	}
 
-	fmt.Printf("✓ Stopped %s\n", appName)
+	printDone("Stopped %s", appName)
 }
\ No newline at end of file
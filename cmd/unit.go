@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/wojtekw92/pm2go/pkg/systemd"
+)
+
+var unitCmd = &cobra.Command{
+	Use:   "unit",
+	Short: "Work with the systemd units of applications",
+}
+
+var unitRenderCmd = &cobra.Command{
+	Use:   "render <name|id>",
+	Short: "Preview the unit file of an application",
+	Long: `Render the unit file of an application from its current configuration,
+without installing it. Units are rendered from the app's unit_template, else
+~/.pm2go/unit.tmpl when it exists, else the built-in template.
+
+Examples:
+  pm2go unit render my-app
+  pm2go unit render my-app --template ./unit.tmpl   # Try a template first`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		templatePath, _ := cmd.Flags().GetString("template")
+		handleUnitRender(args[0], templatePath)
+	},
+}
+
+var unitTemplateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Print the built-in unit template",
+	Long: `Print the built-in unit template, as a starting point for a custom one.
+
+Templates use Go text/template syntax and receive the app's configuration
+(.Name, .Script, .Env, .Cwd, ...) plus .ServiceName, .UserMode, .ServiceUser,
+.WorkingDirectory, .ExecStart, .StandardOutput, .StandardError and
+.SyslogIdentifier.
+
+Examples:
+  pm2go unit template > ~/.pm2go/unit.tmpl`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Print(systemd.DefaultUnitTemplate)
+	},
+}
+
+func init() {
+	unitRenderCmd.Flags().String("template", "", "Render with this template instead")
+	unitCmd.AddCommand(unitRenderCmd)
+	unitCmd.AddCommand(unitTemplateCmd)
+}
+
+func handleUnitRender(identifier, templatePath string) {
+	app, err := manager.GetAppConfig(identifier)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if templatePath != "" {
+		if app.Template, err = filepath.Abs(templatePath); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	unit, err := manager.RenderUnit(app)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "# Rendered from %s\n", manager.UnitTemplateSource(app))
+	fmt.Print(unit)
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Config holds global pm2go settings read from ~/.pm2go/config.json
+type Config struct {
+	LogBackend string            `json:"log_backend,omitempty"` // default log backend: "file" or "journal"
+	Forwarders []ForwarderConfig `json:"forwarders,omitempty"`  // destinations for "pm2go forward"
+	InheritEnv string            `json:"inherit_env,omitempty"` // default shell inheritance: "all", "none" or "PATH,HOME,..."
+	SecretKeys []string          `json:"secret_keys,omitempty"` // extra regular expressions for env names to mask
+}
+
+// ForwarderConfig describes a log forwarding destination
+type ForwarderConfig struct {
+	To            string `json:"to"`                       // e.g. syslog+udp://host:514, tcp://host:5170, https://host/ingest
+	BatchSize     int    `json:"batch_size,omitempty"`     // entries per request or write
+	BufferSize    int    `json:"buffer_size,omitempty"`    // entries kept while the destination is down
+	FlushInterval string `json:"flush_interval,omitempty"` // max delay before a partial batch is sent, e.g. "2s"
+}
+
+// Dir returns the pm2go configuration and state directory (~/.pm2go)
+func Dir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".pm2go")
+}
+
+// Path returns the location of the global configuration file
+func Path() string {
+	return filepath.Join(Dir(), "config.json")
+}
+
+// Load reads the global configuration, returning defaults when the file is missing
+func Load() (*Config, error) {
+	cfg := &Config{}
+
+	data, err := os.ReadFile(Path())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return cfg, fmt.Errorf("failed to read %s: %v", Path(), err)
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return &Config{}, fmt.Errorf("failed to parse %s: %v", Path(), err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,139 @@
+package dotenv
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// keyPattern matches valid variable names
+var keyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// Read parses a dotenv file
+func Read(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	vars, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return vars, nil
+}
+
+// Parse parses KEY=VALUE lines. Blank lines and lines starting with '#' are
+// skipped, an "export " prefix is allowed, single quoted values are taken
+// literally and double quoted values support \n, \t, \" and \\ escapes.
+// Unquoted values end at " #".
+func Parse(data []byte) (map[string]string, error) {
+	vars := make(map[string]string)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNumber)
+		}
+
+		key := strings.TrimSpace(parts[0])
+		if !keyPattern.MatchString(key) {
+			return nil, fmt.Errorf("line %d: invalid variable name '%s'", lineNumber, key)
+		}
+
+		value, err := parseValue(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+		vars[key] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
+
+// parseValue unquotes a value
+func parseValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	switch quote := value[0]; quote {
+	case '\'':
+		end := strings.IndexByte(value[1:], '\'')
+		if end == -1 {
+			return "", fmt.Errorf("unterminated single quoted value")
+		}
+		return value[1 : end+1], nil
+	case '"':
+		var b strings.Builder
+		for i := 1; i < len(value); i++ {
+			c := value[i]
+			switch {
+			case c == '"':
+				return b.String(), nil
+			case c == '\\' && i+1 < len(value):
+				i++
+				switch value[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				case 'r':
+					b.WriteByte('\r')
+				default:
+					b.WriteByte(value[i])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", fmt.Errorf("unterminated double quoted value")
+	}
+
+	// Unquoted: strip trailing comments
+	if index := strings.Index(value, " #"); index != -1 {
+		value = value[:index]
+	}
+	return strings.TrimSpace(value), nil
+}
+
+// valueEscaper escapes a double quoted value for Parse
+var valueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+
+// Format renders variables as sorted KEY=VALUE lines that Parse reads back.
+// Values are single quoted so they are taken literally, unless they hold
+// quotes or line breaks.
+func Format(vars map[string]string) []byte {
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b bytes.Buffer
+	for _, key := range keys {
+		value := vars[key]
+		if strings.ContainsAny(value, "'\n\r") {
+			fmt.Fprintf(&b, "%s=\"%s\"\n", key, valueEscaper.Replace(value))
+		} else {
+			fmt.Fprintf(&b, "%s='%s'\n", key, value)
+		}
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,262 @@
+package ecosystem
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/wojtekw92/pm2go/pkg/systemd"
+	"gopkg.in/yaml.v3"
+)
+
+// Supported ecosystem file formats
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatJS   = "js"
+)
+
+// IsEcosystemFile reports whether a start argument refers to an ecosystem file
+func IsEcosystemFile(path string) bool {
+	named := strings.Contains(filepath.Base(path), "ecosystem")
+	switch DetectFormat(path) {
+	case "":
+		return named
+	case FormatJS:
+		// Scripts like webpack.config.js are started, not loaded
+		return named || definesApps(path)
+	}
+	return true
+}
+
+// DetectFormat returns the ecosystem format implied by a file extension,
+// or an empty string when the extension is not recognised
+func DetectFormat(path string) string {
+	if isJSConfig(path) {
+		return FormatJS
+	}
+	if isProcfile(path) {
+		return FormatProcfile
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON
+	case ".yml", ".yaml":
+		return FormatYAML
+	}
+	return ""
+}
+
+// Load reads and parses an ecosystem file, choosing the parser by extension.
+// JavaScript configs (ecosystem.config.js) are evaluated first and Procfiles
+// are converted; files without a known extension are parsed as JSON. ${VAR}
+// references are resolved from vars and the environment.
+func Load(path string, vars map[string]string) (*systemd.EcosystemConfig, error) {
+	if DetectFormat(path) == FormatProcfile {
+		return loadProcfile(path)
+	}
+
+	document, issues, err := readDocument(path, vars)
+	if err != nil {
+		return nil, err
+	}
+	if len(issues) > 0 {
+		messages := make([]string, len(issues))
+		for i, issue := range issues {
+			messages[i] = issue.String()
+		}
+		return nil, fmt.Errorf("%s", strings.Join(messages, "; "))
+	}
+
+	data, err := json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data, FormatJSON)
+}
+
+// readDocument decodes an ecosystem file into generic JSON values with
+// variables interpolated. Numbers are kept as json.Number.
+func readDocument(path string, vars map[string]string) (interface{}, []Issue, error) {
+	data, err := readJSON(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, nil, err
+	}
+
+	document, issues := interpolate(document, vars)
+	return document, issues, nil
+}
+
+// readJSON returns the content of an ecosystem file as JSON
+func readJSON(path string) ([]byte, error) {
+	format := DetectFormat(path)
+	if format == FormatJS {
+		// Evaluate the module and continue with its JSON representation
+		return loadJS(path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == FormatYAML {
+		return yamlToJSON(data)
+	}
+	return data, nil
+}
+
+// Parse parses ecosystem file content in the given format
+func Parse(data []byte, format string) (*systemd.EcosystemConfig, error) {
+	if format == FormatYAML {
+		converted, err := yamlToJSON(data)
+		if err != nil {
+			return nil, err
+		}
+		data = converted
+	}
+
+	var config systemd.EcosystemConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// yamlToJSON converts a YAML document to JSON so that both formats share the
+// JSON schema of EcosystemConfig
+func yamlToJSON(data []byte) ([]byte, error) {
+	var document interface{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	normalized, err := normalizeYAML(document)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(normalized)
+}
+
+// normalizeYAML converts YAML maps with non-string keys into JSON-compatible maps
+func normalizeYAML(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			converted, err := normalizeYAML(item)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = converted
+		}
+		return v, nil
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted, err := normalizeYAML(item)
+			if err != nil {
+				return nil, err
+			}
+			result[fmt.Sprint(key)] = converted
+		}
+		return result, nil
+	case []interface{}:
+		for i, item := range v {
+			converted, err := normalizeYAML(item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = converted
+		}
+		return v, nil
+	}
+	return value, nil
+}
+
+// Marshal encodes an ecosystem configuration as JSON or YAML
+func Marshal(config *systemd.EcosystemConfig, format string) ([]byte, error) {
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatJSON:
+		return append(data, '\n'), nil
+	case FormatYAML:
+		return jsonToYAML(data)
+	}
+	return nil, fmt.Errorf("unsupported format '%s' (expected %s or %s)", format, FormatJSON, FormatYAML)
+}
+
+// jsonToYAML converts a JSON document to block style YAML, keeping the key order
+func jsonToYAML(data []byte) ([]byte, error) {
+	// JSON is valid YAML; decoding into a node keeps the order of the keys
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	clearYAMLStyle(&document)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// clearYAMLStyle switches a node tree from JSON's flow style to block style
+func clearYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearYAMLStyle(child)
+	}
+}
+
+// ResolveCwd resolves a relative app cwd from the directory of the ecosystem
+// file. Older versions resolved it from the current directory, so a cwd that
+// only exists there is still found there. When both exist, the one next to
+// the file wins and the other is returned as previous.
+func ResolveCwd(cwd, baseDir string) (resolved string, previous string) {
+	if cwd == "" || filepath.IsAbs(cwd) {
+		return cwd, ""
+	}
+	resolved = filepath.Join(baseDir, cwd)
+	// Directories named after the instance don't exist before it starts
+	if strings.Contains(cwd, systemd.PMIDPlaceholder) {
+		return resolved, ""
+	}
+	callerDir, err := os.Getwd()
+	if err != nil {
+		return resolved, ""
+	}
+	fromCaller := filepath.Join(callerDir, cwd)
+	if fromCaller == resolved || !isDir(fromCaller) {
+		return resolved, ""
+	}
+	if !isDir(resolved) {
+		return fromCaller, ""
+	}
+	return resolved, fromCaller
+}
+
+// isDir reports whether a path is an existing directory
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
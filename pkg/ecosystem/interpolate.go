@@ -0,0 +1,160 @@
+package ecosystem
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/wojtekw92/pm2go/pkg/systemd"
+)
+
+// variablePattern matches valid variable names inside ${...}
+var variablePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// interpolate replaces ${VAR} and ${VAR:-default} in every string value of
+// an ecosystem document. Inside an app, ${name} and ${pm_id} refer to the
+// app's own fields; other names come from vars (e.g. an --var-file) and then
+// the environment. "$${" produces a literal "${".
+func interpolate(document interface{}, vars map[string]string) (interface{}, []Issue) {
+	lookup := func(name string) (string, bool) {
+		if value, ok := vars[name]; ok {
+			return value, true
+		}
+		return os.LookupEnv(name)
+	}
+
+	root, ok := document.(map[string]interface{})
+	if !ok {
+		return document, nil
+	}
+
+	var issues []Issue
+	for key, value := range root {
+		if key == "apps" {
+			continue
+		}
+		var found []Issue
+		root[key], found = interpolateValue(value, "$."+key, lookup)
+		issues = append(issues, found...)
+	}
+
+	apps, ok := root["apps"].([]interface{})
+	if !ok {
+		return root, issues
+	}
+
+	for i, item := range apps {
+		path := fmt.Sprintf("$.apps[%d]", i)
+		app, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		// The name is resolved first so other fields can refer to it
+		if name, ok := app["name"].(string); ok {
+			resolved, err := expandVariables(name, lookup)
+			if err != nil {
+				issues = append(issues, Issue{Path: path + ".name", Message: err.Error()})
+			}
+			app["name"] = resolved
+		}
+
+		appLookup := func(name string) (string, bool) {
+			switch name {
+			case "name":
+				if value, ok := app["name"].(string); ok {
+					return value, true
+				}
+			case "pm_id":
+				if id, ok := app["id"].(json.Number); ok && id.String() != "0" {
+					return id.String(), true
+				}
+				// Substituted by the manager once the ID is assigned
+				return systemd.PMIDPlaceholder, true
+			}
+			return lookup(name)
+		}
+
+		for key, value := range app {
+			if key == "name" {
+				continue
+			}
+			var found []Issue
+			app[key], found = interpolateValue(value, path+"."+key, appLookup)
+			issues = append(issues, found...)
+		}
+	}
+
+	return root, issues
+}
+
+// interpolateValue expands variables in the strings of a decoded JSON value
+func interpolateValue(value interface{}, path string, lookup func(string) (string, bool)) (interface{}, []Issue) {
+	switch v := value.(type) {
+	case string:
+		resolved, err := expandVariables(v, lookup)
+		if err != nil {
+			return v, []Issue{{Path: path, Message: err.Error()}}
+		}
+		return resolved, nil
+	case map[string]interface{}:
+		var issues []Issue
+		for key, item := range v {
+			var found []Issue
+			v[key], found = interpolateValue(item, path+"."+key, lookup)
+			issues = append(issues, found...)
+		}
+		return v, issues
+	case []interface{}:
+		var issues []Issue
+		for i, item := range v {
+			var found []Issue
+			v[i], found = interpolateValue(item, fmt.Sprintf("%s[%d]", path, i), lookup)
+			issues = append(issues, found...)
+		}
+		return v, issues
+	}
+	return value, nil
+}
+
+// expandVariables expands ${VAR} and ${VAR:-default} in a string
+func expandVariables(s string, lookup func(string) (string, bool)) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "$${"):
+			b.WriteString("${")
+			i += 2
+		case strings.HasPrefix(s[i:], "${"):
+			end := strings.IndexByte(s[i:], '}')
+			if end == -1 {
+				return s, fmt.Errorf("unterminated variable reference in '%s'", s)
+			}
+			expression := s[i+2 : i+end]
+
+			name, fallback, hasDefault := strings.Cut(expression, ":-")
+			if !variablePattern.MatchString(name) {
+				return s, fmt.Errorf("invalid variable reference '${%s}'", expression)
+			}
+
+			value, ok := lookup(name)
+			switch {
+			case (!ok || value == "") && hasDefault:
+				value = fallback
+			case !ok:
+				return s, fmt.Errorf("variable '%s' is not set (use ${%s:-default} for a default)", name, name)
+			}
+			b.WriteString(value)
+			i += end
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,453 @@
+package ecosystem
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// nodeLoader prints the exported config of a CommonJS or ES module as JSON.
+// Dynamic import handles both: module.exports becomes the default export.
+const nodeLoader = `
+const { pathToFileURL } = require('url');
+import(pathToFileURL(process.argv[1]).href).then((mod) => {
+  let config = mod.default !== undefined ? mod.default : mod;
+  if (typeof config === 'function') config = config();
+  return Promise.resolve(config);
+}).then((config) => {
+  process.stdout.write(JSON.stringify(config));
+}).catch((err) => {
+  process.stderr.write(String(err && err.stack || err) + '\n');
+  process.exit(1);
+});
+`
+
+// isJSConfig reports whether a path looks like a JavaScript ecosystem file
+// (ecosystem.config.js, pm2.config.cjs, ecosystem.js, ...) rather than an app script
+func isJSConfig(path string) bool {
+	base := strings.ToLower(filepath.Base(path))
+	ext := filepath.Ext(base)
+	if ext != ".js" && ext != ".cjs" && ext != ".mjs" {
+		return false
+	}
+	name := strings.TrimSuffix(base, ext)
+	return strings.HasSuffix(name, ".config") || strings.Contains(name, "ecosystem")
+}
+
+// appsKeyPattern finds the apps list of a JavaScript ecosystem file
+var appsKeyPattern = regexp.MustCompile(`\bapps\s*[:=]`)
+
+// definesApps reports whether a JavaScript file defines an apps list, as
+// ecosystem files not named after the ecosystem (pm2.config.js) do
+func definesApps(path string) bool {
+	data, err := os.ReadFile(path)
+	return err == nil && appsKeyPattern.Match(data)
+}
+
+// loadJS evaluates a JavaScript ecosystem file and returns its exports as
+// JSON. The locally installed node is used when available; otherwise a
+// literal-only subset of JavaScript is evaluated.
+func loadJS(path string) ([]byte, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	nodePath, err := exec.LookPath("node")
+	if err != nil {
+		nodePath, err = exec.LookPath("nodejs")
+	}
+	if err != nil {
+		data, err := os.ReadFile(absPath)
+		if err != nil {
+			return nil, err
+		}
+		return evalJSSubset(string(data), filepath.Dir(absPath))
+	}
+
+	cmd := exec.Command(nodePath, "-e", nodeLoader, absPath)
+	cmd.Dir = filepath.Dir(absPath)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("node failed to evaluate %s: %v\n%s", path, err, strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
+
+// exportPattern finds the start of the exported value
+var exportPattern = regexp.MustCompile(`(?m)(module\.exports\s*=|export\s+default)\s*`)
+
+// evalJSSubset evaluates "module.exports = <literal>" without node. Only
+// object/array/string/number/boolean/null literals, string concatenation,
+// __dirname and process.env.NAME are supported.
+func evalJSSubset(source, dir string) ([]byte, error) {
+	loc := exportPattern.FindStringIndex(source)
+	if loc == nil {
+		return nil, fmt.Errorf("node is not installed and no 'module.exports =' or 'export default' was found")
+	}
+
+	p := &jsParser{src: source, pos: loc[1], dir: dir}
+	value, err := p.parseExpression()
+	if err != nil {
+		return nil, fmt.Errorf("node is not installed and the file can't be evaluated without it: %v", err)
+	}
+	return json.Marshal(value)
+}
+
+// jsParser is a small recursive descent parser for JavaScript literals
+type jsParser struct {
+	src string
+	pos int
+	dir string
+}
+
+// errorf reports an error with the current line number
+func (p *jsParser) errorf(format string, args ...interface{}) error {
+	line := strings.Count(p.src[:p.pos], "\n") + 1
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// skipSpace skips whitespace and comments
+func (p *jsParser) skipSpace() {
+	for p.pos < len(p.src) {
+		switch {
+		case unicode.IsSpace(rune(p.src[p.pos])):
+			p.pos++
+		case strings.HasPrefix(p.src[p.pos:], "//"):
+			end := strings.IndexByte(p.src[p.pos:], '\n')
+			if end == -1 {
+				p.pos = len(p.src)
+			} else {
+				p.pos += end + 1
+			}
+		case strings.HasPrefix(p.src[p.pos:], "/*"):
+			end := strings.Index(p.src[p.pos+2:], "*/")
+			if end == -1 {
+				p.pos = len(p.src)
+			} else {
+				p.pos += end + 4
+			}
+		default:
+			return
+		}
+	}
+}
+
+// peek returns the next non-space character without consuming it
+func (p *jsParser) peek() byte {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+// parseExpression parses values joined with '||' (e.g. process.env.PORT || 3000)
+func (p *jsParser) parseExpression() (interface{}, error) {
+	value, err := p.parseConcat()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == '|' && strings.HasPrefix(p.src[p.pos:], "||") {
+		p.pos += 2
+		right, err := p.parseConcat()
+		if err != nil {
+			return nil, err
+		}
+		if !jsTruthy(value) {
+			value = right
+		}
+	}
+	return value, nil
+}
+
+// jsTruthy reports whether a literal value is truthy in JavaScript
+func jsTruthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case float64:
+		return v != 0
+	}
+	return true
+}
+
+// parseConcat parses a value optionally joined with '+'
+func (p *jsParser) parseConcat() (interface{}, error) {
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == '+' {
+		p.pos++
+		right, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		_, leftIsString := value.(string)
+		_, rightIsString := right.(string)
+		switch {
+		case leftIsString || rightIsString:
+			value = jsString(value) + jsString(right)
+		default:
+			lnum, lnumOK := value.(float64)
+			rnum, rnumOK := right.(float64)
+			if !lnumOK || !rnumOK {
+				return nil, p.errorf("unsupported '+' operands")
+			}
+			value = lnum + rnum
+		}
+	}
+	return value, nil
+}
+
+// jsString converts a literal value to a string like JavaScript concatenation
+func jsString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return "undefined"
+	}
+	return fmt.Sprint(value)
+}
+
+// parseValue parses a single literal or supported identifier
+func (p *jsParser) parseValue() (interface{}, error) {
+	c := p.peek()
+	switch {
+	case c == '{':
+		return p.parseObject()
+	case c == '[':
+		return p.parseArray()
+	case c == '"' || c == '\'' || c == '`':
+		return p.parseString()
+	case c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		return p.parseNumber()
+	case c == '(':
+		p.pos++
+		value, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, p.errorf("expected ')'")
+		}
+		p.pos++
+		return value, nil
+	case c == '_' || c == '$' || unicode.IsLetter(rune(c)):
+		return p.parseIdentifier()
+	case c == 0:
+		return nil, p.errorf("unexpected end of file")
+	}
+	return nil, p.errorf("unexpected character '%c'", c)
+}
+
+// readIdentifier reads a dotted identifier such as process.env.HOME
+func (p *jsParser) readIdentifier() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := rune(p.src[p.pos])
+		if c == '_' || c == '$' || c == '.' || unicode.IsLetter(c) || unicode.IsDigit(c) {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.src[start:p.pos]
+}
+
+// parseIdentifier resolves the identifiers available without node
+func (p *jsParser) parseIdentifier() (interface{}, error) {
+	name := p.readIdentifier()
+	switch {
+	case name == "true":
+		return true, nil
+	case name == "false":
+		return false, nil
+	case name == "null", name == "undefined":
+		return nil, nil
+	case name == "__dirname":
+		return p.dir, nil
+	case strings.HasPrefix(name, "process.env."):
+		value, ok := os.LookupEnv(strings.TrimPrefix(name, "process.env."))
+		if !ok {
+			return nil, nil
+		}
+		return value, nil
+	}
+	return nil, p.errorf("unsupported expression '%s'", name)
+}
+
+// parseObject parses an object literal with quoted or bare keys
+func (p *jsParser) parseObject() (interface{}, error) {
+	p.pos++ // '{'
+	result := make(map[string]interface{})
+
+	for {
+		c := p.peek()
+		if c == '}' {
+			p.pos++
+			return result, nil
+		}
+
+		var key string
+		if c == '"' || c == '\'' {
+			value, err := p.parseString()
+			if err != nil {
+				return nil, err
+			}
+			key = value.(string)
+		} else {
+			key = p.readIdentifier()
+			if key == "" || strings.Contains(key, ".") {
+				return nil, p.errorf("invalid object key")
+			}
+		}
+
+		var value interface{}
+		switch p.peek() {
+		case ':':
+			p.pos++
+			parsed, err := p.parseExpression()
+			if err != nil {
+				return nil, err
+			}
+			value = parsed
+		case ',', '}':
+			// Shorthand property ({ name }) refers to a variable
+			return nil, p.errorf("unsupported shorthand property '%s'", key)
+		default:
+			return nil, p.errorf("expected ':' after '%s'", key)
+		}
+		result[key] = value
+
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+		default:
+			return nil, p.errorf("expected ',' or '}'")
+		}
+	}
+}
+
+// parseArray parses an array literal
+func (p *jsParser) parseArray() (interface{}, error) {
+	p.pos++ // '['
+	result := []interface{}{}
+
+	for {
+		if p.peek() == ']' {
+			p.pos++
+			return result, nil
+		}
+
+		value, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, value)
+
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+		default:
+			return nil, p.errorf("expected ',' or ']'")
+		}
+	}
+}
+
+// parseString parses a single, double or backtick quoted string. Template
+// literals may only interpolate the identifiers parseIdentifier supports.
+func (p *jsParser) parseString() (interface{}, error) {
+	quote := p.src[p.pos]
+	p.pos++
+
+	var b strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return b.String(), nil
+		case c == '\\' && p.pos+1 < len(p.src):
+			p.pos++
+			switch esc := p.src[p.pos]; esc {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case '\n':
+				// Line continuation
+			default:
+				b.WriteByte(esc)
+			}
+			p.pos++
+		case quote == '`' && strings.HasPrefix(p.src[p.pos:], "${"):
+			p.pos += 2
+			value, err := p.parseExpression()
+			if err != nil {
+				return nil, err
+			}
+			if p.peek() != '}' {
+				return nil, p.errorf("expected '}' in template literal")
+			}
+			p.pos++
+			b.WriteString(jsString(value))
+		case c == '\n' && quote != '`':
+			return nil, p.errorf("unterminated string")
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	return nil, p.errorf("unterminated string")
+}
+
+// parseNumber parses a numeric literal
+func (p *jsParser) parseNumber() (interface{}, error) {
+	start := p.pos
+	if p.src[p.pos] == '-' {
+		p.pos++
+	}
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		exponentSign := (c == '+' || c == '-') && p.pos > start && (p.src[p.pos-1] == 'e' || p.src[p.pos-1] == 'E')
+		if strings.IndexByte(".0123456789eExX_abcdefABCDEF", c) >= 0 || exponentSign {
+			p.pos++
+			continue
+		}
+		break
+	}
+
+	text := strings.ReplaceAll(p.src[start:p.pos], "_", "")
+	if value, err := strconv.ParseFloat(text, 64); err == nil {
+		return value, nil
+	}
+	if value, err := strconv.ParseInt(text, 0, 64); err == nil {
+		return float64(value), nil
+	}
+	return nil, p.errorf("invalid number '%s'", text)
+}
@@ -0,0 +1,174 @@
+package ecosystem
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/wojtekw92/pm2go/pkg/systemd"
+)
+
+// FormatProcfile is a Heroku-style Procfile ("<type>: <command>" lines)
+const FormatProcfile = "procfile"
+
+// processTypePattern matches valid Procfile process types
+var processTypePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// isProcfile reports whether a path names a Procfile (Procfile, Procfile.dev, ...)
+func isProcfile(path string) bool {
+	base := filepath.Base(path)
+	return base == "Procfile" || strings.HasPrefix(base, "Procfile.")
+}
+
+// ProcessType is a single entry of a Procfile
+type ProcessType struct {
+	Name    string
+	Command string
+	Line    int
+}
+
+// ParseProcfile parses "<type>: <command>" lines, skipping blank lines and
+// comments
+func ParseProcfile(data []byte) ([]ProcessType, error) {
+	var types []ProcessType
+	seen := make(map[string]int)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, command, found := strings.Cut(line, ":")
+		name, command = strings.TrimSpace(name), strings.TrimSpace(command)
+		switch {
+		case !found:
+			return nil, fmt.Errorf("line %d: expected '<type>: <command>'", lineNumber)
+		case !processTypePattern.MatchString(name):
+			return nil, fmt.Errorf("line %d: invalid process type '%s'", lineNumber, name)
+		case command == "":
+			return nil, fmt.Errorf("line %d: process type '%s' has no command", lineNumber, name)
+		}
+		if first, ok := seen[name]; ok {
+			return nil, fmt.Errorf("line %d: process type '%s' is already defined on line %d", lineNumber, name, first)
+		}
+		seen[name] = lineNumber
+
+		types = append(types, ProcessType{Name: name, Command: command, Line: lineNumber})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(types) == 0 {
+		return nil, fmt.Errorf("no process types defined")
+	}
+	return types, nil
+}
+
+// ProcfileAppName returns the app name of a process type: <dir>-<type>
+func ProcfileAppName(path, processType string) string {
+	dir := filepath.Dir(path)
+	if absDir, err := filepath.Abs(dir); err == nil {
+		dir = absDir
+	}
+	return filepath.Base(dir) + "-" + processType
+}
+
+// loadProcfile turns a Procfile into one app per process type. Commands run
+// through the shell from the Procfile's directory, with the variables of a
+// sibling .env file.
+func loadProcfile(path string) (*systemd.EcosystemConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	types, err := ParseProcfile(data)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+
+	var envFiles []string
+	envPath := filepath.Join(dir, ".env")
+	if _, err := os.Stat(envPath); err == nil {
+		envFiles = []string{envPath}
+	}
+
+	config := &systemd.EcosystemConfig{}
+	for _, processType := range types {
+		config.Apps = append(config.Apps, systemd.AppConfig{
+			Name:        ProcfileAppName(path, processType.Name),
+			Interpreter: "sh -c",
+			Script:      processType.Command,
+			Cwd:         dir,
+			EnvFiles:    envFiles,
+		})
+	}
+	return config, nil
+}
+
+// ParseFormation parses a formation such as "web=2,worker=1"
+func ParseFormation(spec string) (map[string]int, error) {
+	formation := make(map[string]int)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, count, found := strings.Cut(part, "=")
+		n, err := strconv.Atoi(strings.TrimSpace(count))
+		if !found || err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid formation '%s' (expected <type>=<count>)", part)
+		}
+		formation[strings.TrimSpace(name)] = n
+	}
+	return formation, nil
+}
+
+// ApplyFormation sets the instance counts of Procfile apps. Types with a
+// count of 0 are removed; unknown types are an error.
+func ApplyFormation(config *systemd.EcosystemConfig, path string, formation map[string]int) error {
+	byName := make(map[string]int)
+	for processType, count := range formation {
+		byName[ProcfileAppName(path, processType)] = count
+	}
+
+	var apps []systemd.AppConfig
+	for _, app := range config.Apps {
+		count, ok := byName[app.Name]
+		if !ok {
+			apps = append(apps, app)
+			continue
+		}
+		delete(byName, app.Name)
+		if count == 0 {
+			continue
+		}
+		app.Instances = count
+		apps = append(apps, app)
+	}
+
+	for processType := range formation {
+		if _, unknown := byName[ProcfileAppName(path, processType)]; unknown {
+			return fmt.Errorf("process type '%s' is not defined in %s", processType, path)
+		}
+	}
+
+	config.Apps = apps
+	return nil
+}
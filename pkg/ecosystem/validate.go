@@ -0,0 +1,577 @@
+package ecosystem
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/wojtekw92/pm2go/pkg/dotenv"
+	"github.com/wojtekw92/pm2go/pkg/systemd"
+)
+
+// Issue is a problem found in an ecosystem file. Path is a JSON path such
+// as $.apps[0].script.
+type Issue struct {
+	Path    string
+	Message string
+	Warning bool // warnings don't prevent the file from being started
+}
+
+// String formats an issue as "<path>: <message>"
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s", i.Path, i.Message)
+}
+
+// HasErrors reports whether any of the issues is an error
+func HasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if !issue.Warning {
+			return true
+		}
+	}
+	return false
+}
+
+// unsupportedKeys are PM2 options that pm2go accepts but ignores
+var unsupportedKeys = map[string]bool{
+	"watch": true, "ignore_watch": true, "watch_options": true, "exec_mode": true,
+	"max_memory_restart": true, "autorestart": true, "max_restarts": true,
+	"min_uptime": true, "restart_delay": true, "exp_backoff_restart_delay": true,
+	"kill_timeout": true, "listen_timeout": true, "wait_ready": true,
+	"node_args": true, "interpreter_args": true, "time": true,
+	"log_date_format": true, "combine_logs": true, "pid_file": true,
+	"namespace": true, "version": true, "cron_restart": true, "vizion": true,
+	"source_map_support": true, "instance_var": true, "increment_var": true,
+	"shutdown_with_message": true, "treekill": true, "filter_env": true,
+	"append_env_to_name": true, "force": true, "post_update": true,
+}
+
+// appFieldKinds maps the JSON keys of AppConfig to their Go kinds
+func appFieldKinds() map[string]reflect.Kind {
+	kinds := make(map[string]reflect.Kind)
+	t := reflect.TypeOf(systemd.AppConfig{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		kinds[name] = t.Field(i).Type.Kind()
+	}
+	return kinds
+}
+
+// Validate checks an ecosystem file and returns every issue found. An error
+// is returned only when the file can't be read or parsed at all.
+func Validate(path string, vars map[string]string) ([]Issue, error) {
+	if DetectFormat(path) == FormatProcfile {
+		return validateProcfile(path)
+	}
+
+	document, issues, err := readDocument(path, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	baseDir, _ := filepath.Abs(filepath.Dir(path))
+	return append(issues, validateDocument(document, baseDir)...), nil
+}
+
+// validateDocument checks the decoded content of an ecosystem file located
+// in baseDir
+func validateDocument(document interface{}, baseDir string) []Issue {
+	var issues []Issue
+
+	root, ok := document.(map[string]interface{})
+	if !ok {
+		return []Issue{{Path: "$", Message: fmt.Sprintf("expected an object with an \"apps\" list, got %s", jsonType(document))}}
+	}
+
+	for _, key := range sortedKeys(root) {
+		switch key {
+		case "apps":
+		case "deploy":
+			issues = append(issues, Issue{Path: "$.deploy", Message: "not supported by pm2go, ignored", Warning: true})
+		default:
+			issues = append(issues, Issue{Path: "$." + key, Message: "unknown key"})
+		}
+	}
+
+	apps, ok := root["apps"].([]interface{})
+	if !ok {
+		if _, present := root["apps"]; present {
+			return append(issues, Issue{Path: "$.apps", Message: fmt.Sprintf("expected a list, got %s", jsonType(root["apps"]))})
+		}
+		return append(issues, Issue{Path: "$.apps", Message: "missing \"apps\" list"})
+	}
+	if len(apps) == 0 {
+		issues = append(issues, Issue{Path: "$.apps", Message: "no apps defined", Warning: true})
+	}
+
+	names := make(map[string]int)
+	ids := make(map[string]int)
+	kinds := appFieldKinds()
+	for i, item := range apps {
+		path := fmt.Sprintf("$.apps[%d]", i)
+		app, ok := item.(map[string]interface{})
+		if !ok {
+			issues = append(issues, Issue{Path: path, Message: fmt.Sprintf("expected an object, got %s", jsonType(item))})
+			continue
+		}
+
+		issues = append(issues, validateApp(path, app, kinds, baseDir)...)
+
+		if name, ok := app["name"].(string); ok && name != "" {
+			if first, seen := names[name]; seen {
+				issues = append(issues, Issue{Path: path + ".name", Message: fmt.Sprintf("duplicate name '%s' (also used by $.apps[%d])", name, first)})
+			} else {
+				names[name] = i
+			}
+		}
+
+		if id, ok := app["id"].(json.Number); ok && id.String() != "0" {
+			if first, seen := ids[id.String()]; seen {
+				issues = append(issues, Issue{Path: path + ".id", Message: fmt.Sprintf("id %s is also used by $.apps[%d]", id, first)})
+			} else {
+				ids[id.String()] = i
+			}
+		}
+	}
+
+	return issues
+}
+
+// validateApp checks the keys, types and referenced paths of a single app
+func validateApp(path string, app map[string]interface{}, kinds map[string]reflect.Kind, baseDir string) []Issue {
+	var issues []Issue
+
+	for _, key := range sortedKeys(app) {
+		value := app[key]
+		keyPath := path + "." + key
+
+		if key == "env_file" {
+			issues = append(issues, validateEnvFiles(keyPath, value, baseDir)...)
+			continue
+		}
+		if key == "uid" || key == "gid" {
+			switch value.(type) {
+			case string, json.Number:
+			default:
+				issues = append(issues, Issue{Path: keyPath, Message: fmt.Sprintf("expected a name or an ID, got %s", jsonType(value))})
+			}
+			continue
+		}
+		if key == "inherit_env" {
+			issues = append(issues, validateInheritEnv(keyPath, value)...)
+			continue
+		}
+		if key == "systemd" {
+			issues = append(issues, validateSystemd(keyPath, value)...)
+			continue
+		}
+		if key == "args" {
+			issues = append(issues, validateArgs(keyPath, value)...)
+			continue
+		}
+		if key == "env" || (strings.HasPrefix(key, "env_") && len(key) > len("env_")) {
+			issues = append(issues, validateEnv(keyPath, value)...)
+			continue
+		}
+
+		kind, known := kinds[key]
+		switch {
+		case unsupportedKeys[key]:
+			issues = append(issues, Issue{Path: keyPath, Message: "not supported by pm2go, ignored", Warning: true})
+		case !known:
+			issues = append(issues, Issue{Path: keyPath, Message: "unknown key"})
+		default:
+			if message := checkKind(value, kind); message != "" {
+				issues = append(issues, Issue{Path: keyPath, Message: message})
+			}
+		}
+	}
+
+	name, _ := app["name"].(string)
+	if name == "" {
+		issues = append(issues, Issue{Path: path + ".name", Message: "name is required"})
+	} else if strings.ContainsAny(name, "/ \t") {
+		issues = append(issues, Issue{Path: path + ".name", Message: fmt.Sprintf("name '%s' can't contain slashes or whitespace", name)})
+	}
+
+	issues = append(issues, validateAccount(path, app)...)
+
+	if sandbox, ok := app["sandbox"].(string); ok {
+		if err := systemd.ValidateSandbox(sandbox, nil); err != nil {
+			issues = append(issues, Issue{Path: path + ".sandbox", Message: err.Error()})
+		}
+	}
+	if overrides, ok := app["sandbox_override"].(map[string]interface{}); ok {
+		for _, directive := range sortedKeys(overrides) {
+			value, isString := overrides[directive].(string)
+			if !isString {
+				issues = append(issues, Issue{Path: path + ".sandbox_override." + directive, Message: fmt.Sprintf("expected a string, got %s", jsonType(overrides[directive]))})
+			} else if err := systemd.ValidateSandbox("", map[string]string{directive: value}); err != nil {
+				issues = append(issues, Issue{Path: path + ".sandbox_override." + directive, Message: err.Error()})
+			}
+		}
+	}
+
+	if template, ok := app["unit_template"].(string); ok && template != "" {
+		if !filepath.IsAbs(template) {
+			template = filepath.Join(baseDir, template)
+		}
+		if err := systemd.ValidateUnitTemplate(template); err != nil {
+			issues = append(issues, Issue{Path: path + ".unit_template", Message: err.Error()})
+		}
+	}
+
+	if backend, ok := app["log_backend"].(string); ok && backend != systemd.LogBackendFile && backend != systemd.LogBackendJournal {
+		issues = append(issues, Issue{Path: path + ".log_backend", Message: fmt.Sprintf("unknown log backend '%s' (expected '%s' or '%s')", backend, systemd.LogBackendFile, systemd.LogBackendJournal)})
+	}
+
+	if instances, ok := app["instances"].(json.Number); ok {
+		if n, err := instances.Int64(); err == nil && n < 0 {
+			issues = append(issues, Issue{Path: path + ".instances", Message: "must not be negative"})
+		}
+	}
+
+	if interpreter, ok := app["interpreter"].(string); ok && strings.TrimSpace(interpreter) != "" {
+		words, err := systemd.SplitArgs(interpreter)
+		if err != nil {
+			issues = append(issues, Issue{Path: path + ".interpreter", Message: err.Error()})
+		} else if _, err := exec.LookPath(words[0]); err != nil {
+			issues = append(issues, Issue{Path: path + ".interpreter", Message: fmt.Sprintf("interpreter '%s' not found", words[0])})
+		}
+	}
+
+	// Like env files, a relative cwd is resolved from the ecosystem file's
+	// directory, which is also where apps without a cwd run
+	workingDir := baseDir
+	if cwd, ok := app["cwd"].(string); ok && cwd != "" && !strings.Contains(cwd, systemd.PMIDPlaceholder) {
+		cwd, previous := ResolveCwd(cwd, baseDir)
+		if previous != "" {
+			issues = append(issues, Issue{Path: path + ".cwd", Message: fmt.Sprintf("resolved from the ecosystem file's directory to %s, not from the current directory to %s", cwd, previous), Warning: true})
+		}
+		info, err := os.Stat(cwd)
+		switch {
+		case err != nil:
+			issues = append(issues, Issue{Path: path + ".cwd", Message: fmt.Sprintf("directory %s does not exist", cwd)})
+			return issues
+		case !info.IsDir():
+			issues = append(issues, Issue{Path: path + ".cwd", Message: fmt.Sprintf("%s is not a directory", cwd)})
+			return issues
+		}
+		workingDir = cwd
+	}
+
+	script, isString := app["script"].(string)
+	_, present := app["script"]
+	if script == "" {
+		if isString || !present {
+			issues = append(issues, Issue{Path: path + ".script", Message: "script is required"})
+		}
+	} else if strings.Contains(script, systemd.PMIDPlaceholder) {
+		// Resolved when the app is started
+	} else if message := checkScript(script, workingDir); message != "" {
+		issues = append(issues, Issue{Path: path + ".script", Message: message})
+	}
+
+	return issues
+}
+
+// checkScript reports a missing script file. Bare command names found in
+// PATH (e.g. "npm") are accepted.
+func checkScript(script, workingDir string) string {
+	scriptPath := script
+	if !filepath.IsAbs(scriptPath) {
+		scriptPath = filepath.Join(workingDir, script)
+	}
+	if _, err := os.Stat(scriptPath); err == nil {
+		return ""
+	}
+	if !strings.Contains(script, "/") {
+		if _, err := exec.LookPath(script); err == nil {
+			return ""
+		}
+	}
+	return fmt.Sprintf("script %s not found", scriptPath)
+}
+
+// validateEnv checks that an env block is an object of scalar values
+func validateEnv(path string, value interface{}) []Issue {
+	env, ok := value.(map[string]interface{})
+	if !ok {
+		return []Issue{{Path: path, Message: fmt.Sprintf("expected an object, got %s", jsonType(value))}}
+	}
+
+	var issues []Issue
+	for _, key := range sortedKeys(env) {
+		switch env[key].(type) {
+		case map[string]interface{}, []interface{}:
+			issues = append(issues, Issue{Path: path + "." + key, Message: fmt.Sprintf("%s value is passed as JSON text", jsonType(env[key])), Warning: true})
+		}
+	}
+	return issues
+}
+
+// validateEnvFiles checks that env_file is a path or a list of paths to
+// readable dotenv files, relative to the ecosystem file
+func validateEnvFiles(path string, value interface{}, baseDir string) []Issue {
+	var files []interface{}
+	switch v := value.(type) {
+	case string:
+		files = []interface{}{v}
+	case []interface{}:
+		files = v
+	default:
+		return []Issue{{Path: path, Message: fmt.Sprintf("expected a string or a list of strings, got %s", jsonType(value))}}
+	}
+
+	var issues []Issue
+	for i, item := range files {
+		itemPath := path
+		if _, isList := value.([]interface{}); isList {
+			itemPath = fmt.Sprintf("%s[%d]", path, i)
+		}
+
+		file, ok := item.(string)
+		if !ok {
+			issues = append(issues, Issue{Path: itemPath, Message: fmt.Sprintf("expected a string, got %s", jsonType(item))})
+			continue
+		}
+		if strings.Contains(file, systemd.PMIDPlaceholder) {
+			continue
+		}
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(baseDir, file)
+		}
+		if _, err := dotenv.Read(file); err != nil {
+			issues = append(issues, Issue{Path: itemPath, Message: err.Error()})
+		}
+	}
+	return issues
+}
+
+// validateAccount checks that the user and group of an app (or PM2's uid
+// and gid) exist
+func validateAccount(path string, app map[string]interface{}) []Issue {
+	account := func(keys ...string) (string, string) {
+		for _, key := range keys {
+			switch v := app[key].(type) {
+			case string:
+				return v, key
+			case json.Number:
+				return v.String(), key
+			}
+		}
+		return "", ""
+	}
+
+	var issues []Issue
+	userName, userKey := account("user", "uid")
+	groupName, groupKey := account("group", "gid")
+	if userName != "" {
+		if _, _, err := systemd.LookupAccount(userName, ""); err != nil {
+			issues = append(issues, Issue{Path: path + "." + userKey, Message: err.Error()})
+		}
+	}
+	if groupName != "" {
+		if _, _, err := systemd.LookupAccount("", groupName); err != nil {
+			issues = append(issues, Issue{Path: path + "." + groupKey, Message: err.Error()})
+		}
+	}
+	if (userName != "" || groupName != "") && os.Getuid() != 0 {
+		key := userKey
+		if key == "" {
+			key = groupKey
+		}
+		issues = append(issues, Issue{Path: path + "." + key, Message: "only applies in system mode (run pm2go as root)", Warning: true})
+	}
+	return issues
+}
+
+// validateInheritEnv checks that inherit_env is all, none or a list of
+// variable names
+func validateInheritEnv(path string, value interface{}) []Issue {
+	policy, ok := value.(string)
+	if list, isList := value.([]interface{}); isList {
+		names := make([]string, 0, len(list))
+		for _, item := range list {
+			name, ok := item.(string)
+			if !ok {
+				return []Issue{{Path: path, Message: fmt.Sprintf("expected a list of strings, got a %s item", jsonType(item))}}
+			}
+			names = append(names, name)
+		}
+		policy, ok = strings.Join(names, ","), true
+	}
+	if !ok {
+		return []Issue{{Path: path, Message: fmt.Sprintf("expected a string or a list of strings, got %s", jsonType(value))}}
+	}
+
+	if err := systemd.ValidateInheritEnv(policy); err != nil {
+		return []Issue{{Path: path, Message: err.Error()}}
+	}
+	return nil
+}
+
+// validateArgs checks that args is a command line string or a list of
+// strings
+func validateArgs(path string, value interface{}) []Issue {
+	switch args := value.(type) {
+	case string:
+		if _, err := systemd.SplitArgs(args); err != nil {
+			return []Issue{{Path: path, Message: err.Error()}}
+		}
+	case []interface{}:
+		for i, item := range args {
+			if _, ok := item.(string); !ok {
+				return []Issue{{Path: fmt.Sprintf("%s[%d]", path, i), Message: fmt.Sprintf("expected a string, got %s", jsonType(item))}}
+			}
+		}
+	default:
+		return []Issue{{Path: path, Message: fmt.Sprintf("expected a string or a list of strings, got %s", jsonType(value))}}
+	}
+	return nil
+}
+
+// validateSystemd checks that systemd is an object of unit, service and
+// install sections holding scalar directive values
+func validateSystemd(path string, value interface{}) []Issue {
+	sections, ok := value.(map[string]interface{})
+	if !ok {
+		return []Issue{{Path: path, Message: fmt.Sprintf("expected an object, got %s", jsonType(value))}}
+	}
+
+	var issues []Issue
+	for _, section := range sortedKeys(sections) {
+		sectionPath := path + "." + section
+		if err := systemd.ValidateSystemdSections(systemd.SystemdSections{strings.ToLower(section): nil}); err != nil {
+			issues = append(issues, Issue{Path: sectionPath, Message: err.Error()})
+			continue
+		}
+		directives, ok := sections[section].(map[string]interface{})
+		if !ok {
+			issues = append(issues, Issue{Path: sectionPath, Message: fmt.Sprintf("expected an object, got %s", jsonType(sections[section]))})
+			continue
+		}
+		for _, name := range sortedKeys(directives) {
+			var text string
+			switch v := directives[name].(type) {
+			case string:
+				text = v
+			case json.Number, bool:
+				text = fmt.Sprint(v)
+			default:
+				issues = append(issues, Issue{Path: sectionPath + "." + name, Message: fmt.Sprintf("expected a string, got %s", jsonType(v))})
+				continue
+			}
+			if err := systemd.ValidateSystemdSections(systemd.SystemdSections{strings.ToLower(section): {name: text}}); err != nil {
+				issues = append(issues, Issue{Path: sectionPath + "." + name, Message: err.Error()})
+			}
+		}
+	}
+	return issues
+}
+
+// checkKind compares a decoded JSON value with the Go kind of its field
+func checkKind(value interface{}, kind reflect.Kind) string {
+	expected := ""
+	switch kind {
+	case reflect.String:
+		if _, ok := value.(string); !ok {
+			expected = "a string"
+		}
+	case reflect.Int:
+		number, ok := value.(json.Number)
+		if _, err := number.Int64(); !ok || err != nil {
+			expected = "an integer"
+		}
+	case reflect.Bool:
+		if _, ok := value.(bool); !ok {
+			expected = "a boolean"
+		}
+	case reflect.Map:
+		if _, ok := value.(map[string]interface{}); !ok {
+			expected = "an object"
+		}
+	}
+
+	if expected == "" {
+		return ""
+	}
+	return fmt.Sprintf("expected %s, got %s", expected, jsonType(value))
+}
+
+// jsonType names the JSON type of a decoded value
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// sortedKeys returns the keys of a JSON object in a stable order
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// validateProcfile checks that the command of every process type exists
+func validateProcfile(path string) ([]Issue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	types, err := ParseProcfile(data)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, _ := filepath.Abs(filepath.Dir(path))
+	var issues []Issue
+	envPath := filepath.Join(dir, ".env")
+	if _, err := os.Stat(envPath); err == nil {
+		if _, err := dotenv.Read(envPath); err != nil {
+			issues = append(issues, Issue{Path: ".env", Message: err.Error()})
+		}
+	}
+	for _, processType := range types {
+		// Skip leading VAR=value assignments
+		fields := strings.Fields(processType.Command)
+		for len(fields) > 1 && strings.Contains(fields[0], "=") {
+			fields = fields[1:]
+		}
+		command := fields[0]
+		if strings.ContainsAny(command, "$`") {
+			continue
+		}
+		if message := checkScript(command, dir); message != "" {
+			issues = append(issues, Issue{
+				Path:    fmt.Sprintf("line %d (%s)", processType.Line, processType.Name),
+				Message: fmt.Sprintf("command '%s' not found", command),
+			})
+		}
+	}
+	return issues, nil
+}
@@ -0,0 +1,120 @@
+package export
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+
+	"github.com/wojtekw92/pm2go/pkg/systemd"
+	"gopkg.in/yaml.v3"
+)
+
+// composeFile is a docker-compose.yml document
+type composeFile struct {
+	Services map[string]composeService `yaml:"services"`
+}
+
+// composeService is a single service of a compose file
+type composeService struct {
+	Image      string          `yaml:"image"`
+	WorkingDir string          `yaml:"working_dir"`
+	Command    []string        `yaml:"command"`
+	Volumes    []string        `yaml:"volumes"`
+	EnvFile    []string        `yaml:"env_file,omitempty"`
+	Restart    string          `yaml:"restart"`
+	Logging    *composeLogging `yaml:"logging,omitempty"`
+}
+
+// composeLogging is the logging driver of a compose service
+type composeLogging struct {
+	Driver  string            `yaml:"driver"`
+	Options map[string]string `yaml:"options,omitempty"`
+}
+
+// composeWorkDir is where an app's working directory is mounted
+const composeWorkDir = "/app"
+
+// composeImages are the images apps run in, by interpreter name prefix
+var composeImages = []struct {
+	Prefix string
+	Image  string
+}{
+	{"python", "python:3-slim"},
+	{"node", "node:lts-slim"},
+	{"bun", "oven/bun:latest"},
+	{"deno", "denoland/deno:latest"},
+	{"ruby", "ruby:slim"},
+	{"php", "php:cli"},
+	{"java", "eclipse-temurin:21-jre"},
+}
+
+// defaultComposeImage runs scripts and shell commands
+const defaultComposeImage = "debian:stable-slim"
+
+// composeEscaper keeps compose from interpolating variables in values
+var composeEscaper = strings.NewReplacer("$", "$$")
+
+// EnvFileName returns the name of the file an exported app reads its
+// environment from
+func EnvFileName(app systemd.AppConfig) string {
+	return app.Name + ".env"
+}
+
+// Compose renders apps as docker-compose services. Each app runs in an
+// image matching its interpreter with its working directory mounted at
+// /app, and reads its environment from EnvFileName(app) next to the file.
+func Compose(apps []systemd.AppConfig) ([]byte, error) {
+	file := composeFile{Services: make(map[string]composeService)}
+	for _, app := range apps {
+		argv := systemd.ExecArgv(app)
+		image := defaultComposeImage
+		if len(argv) > len(app.Args)+1 {
+			// Interpreters come from the image, not the host
+			argv[0] = filepath.Base(argv[0])
+			image = composeImage(argv[0])
+		}
+		for i, arg := range argv {
+			// Scripts inside the working directory are found under /app
+			if rel, err := filepath.Rel(app.Cwd, arg); err == nil && filepath.IsAbs(arg) && !strings.HasPrefix(rel, "..") {
+				arg = filepath.Join(composeWorkDir, rel)
+			}
+			argv[i] = composeEscaper.Replace(arg)
+		}
+
+		service := composeService{
+			Image:      image,
+			WorkingDir: composeWorkDir,
+			Command:    argv,
+			Volumes:    []string{composeEscaper.Replace(app.Cwd) + ":" + composeWorkDir},
+			Restart:    "always",
+		}
+		if len(app.Env) > 0 {
+			service.EnvFile = []string{EnvFileName(app)}
+		}
+		if app.LogBackend == systemd.LogBackendJournal {
+			service.Logging = &composeLogging{Driver: "journald", Options: map[string]string{"tag": app.Name}}
+		}
+		file.Services[app.Name] = service
+	}
+
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(file); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// composeImage picks the image for an interpreter
+func composeImage(interpreter string) string {
+	for _, candidate := range composeImages {
+		if strings.HasPrefix(interpreter, candidate.Prefix) {
+			return candidate.Image
+		}
+	}
+	return defaultComposeImage
+}
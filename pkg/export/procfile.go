@@ -0,0 +1,90 @@
+package export
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/wojtekw92/pm2go/pkg/systemd"
+)
+
+// invalidProcessTypeChars are replaced in app names used as process types
+var invalidProcessTypeChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// Procfile renders apps as Procfile entries run from dir, and returns the
+// variables all of them share for a .env file next to it. Other variables
+// are exported in front of the command of the app that sets them.
+func Procfile(apps []systemd.AppConfig, dir string) ([]byte, map[string]string) {
+	shared := sharedEnv(apps)
+
+	var b strings.Builder
+	for _, app := range apps {
+		var steps []string
+		if app.Cwd != "" && filepath.Clean(app.Cwd) != filepath.Clean(dir) {
+			steps = append(steps, "cd "+systemd.JoinArgs([]string{app.Cwd}))
+		}
+
+		keys := make([]string, 0, len(app.Env))
+		for key := range app.Env {
+			if _, ok := shared[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			steps = append(steps, exportStep(key, app.Env[key]))
+		}
+
+		steps = append(steps, shellCommand(app))
+		name := invalidProcessTypeChars.ReplaceAllString(app.Name, "-")
+		fmt.Fprintf(&b, "%s: %s\n", name, strings.Join(steps, " && "))
+	}
+	return []byte(b.String()), shared
+}
+
+// sharedEnv returns the variables every app sets to the same value
+func sharedEnv(apps []systemd.AppConfig) map[string]string {
+	shared := make(map[string]string)
+	if len(apps) == 0 {
+		return shared
+	}
+	for key, value := range apps[0].Env {
+		shared[key] = value
+	}
+	for _, app := range apps[1:] {
+		for key, value := range shared {
+			if current, ok := app.Env[key]; !ok || current != value {
+				delete(shared, key)
+			}
+		}
+	}
+	return shared
+}
+
+// exportStep exports a variable in the shell. Procfile entries are single
+// lines, so line breaks are written as \n for printf to restore. Command
+// substitution drops trailing line breaks, which PEM keys and certificates
+// end with, so an x is printed after the value and cut off again.
+func exportStep(key, value string) string {
+	if !strings.ContainsAny(value, "\n\r") {
+		return "export " + key + "=" + systemd.JoinArgs([]string{value})
+	}
+	escaped := strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`).Replace(value)
+	return "export " + key + `="$(printf '%b' ` + systemd.JoinArgs([]string{escaped}) + `; printf x)" && ` +
+		"export " + key + `="${` + key + `%x}"`
+}
+
+// shellCommand returns the command of an app as shell syntax. Apps that
+// already run a shell command (sh -c "...") keep it as it is.
+func shellCommand(app systemd.AppConfig) string {
+	argv := systemd.ExecArgv(app)
+	if len(argv) == 3 && argv[1] == "-c" {
+		switch filepath.Base(argv[0]) {
+		case "sh", "bash":
+			return argv[2]
+		}
+	}
+	return "exec " + systemd.JoinArgs(argv)
+}
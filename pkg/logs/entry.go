@@ -0,0 +1,61 @@
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Stream names used to tag log entries
+const (
+	StreamOut      = "out"
+	StreamErr      = "err"
+	StreamCombined = "combined" // stdout and stderr in one file or the journal
+)
+
+// Entry represents a single log line produced by an application
+type Entry struct {
+	App     string
+	ID      int
+	Stream  string
+	Time    time.Time
+	Message string
+}
+
+// HasTime reports whether a timestamp could be determined for the entry
+func (e Entry) HasTime() bool {
+	return !e.Time.IsZero()
+}
+
+// Format renders an entry PM2-style ("0|app | message") or as-is in raw mode
+func Format(e Entry, raw bool) string {
+	if raw {
+		return e.Message
+	}
+	return fmt.Sprintf("%d|%s | %s", e.ID, e.App, e.Message)
+}
+
+// FormatJSON renders an entry as a single line of JSON
+func FormatJSON(e Entry) string {
+	out := struct {
+		App       string `json:"app"`
+		ID        int    `json:"pm_id"`
+		Stream    string `json:"stream"`
+		Timestamp string `json:"timestamp,omitempty"`
+		Message   string `json:"message"`
+	}{
+		App:     e.App,
+		ID:      e.ID,
+		Stream:  e.Stream,
+		Message: e.Message,
+	}
+	if e.HasTime() {
+		out.Timestamp = e.Time.Format(time.RFC3339Nano)
+	}
+
+	data, err := json.Marshal(out)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,128 @@
+package logs
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Filter selects which log entries are shown
+type Filter struct {
+	Out   bool           // include stdout entries
+	Err   bool           // include stderr entries
+	Grep  *regexp.Regexp // only entries whose message matches
+	Since time.Time      // only entries at or after this time
+	Until time.Time      // only entries at or before this time
+}
+
+// timeFiltered reports whether the filter restricts entries by time
+func (f Filter) timeFiltered() bool {
+	return !f.Since.IsZero() || !f.Until.IsZero()
+}
+
+// WantsStream reports whether entries from the given stream can match
+func (f Filter) WantsStream(stream string) bool {
+	// Selecting neither stream means both
+	if !f.Out && !f.Err {
+		return true
+	}
+	switch stream {
+	case StreamOut:
+		return f.Out
+	case StreamErr:
+		return f.Err
+	default:
+		// Combined logs carry both streams and can't be split by stream
+		return f.Out && f.Err
+	}
+}
+
+// Match reports whether an entry passes the filter
+func (f Filter) Match(e Entry) bool {
+	if !f.WantsStream(e.Stream) {
+		return false
+	}
+
+	if f.timeFiltered() {
+		// Entries without a timestamp can't be placed in the window
+		if !e.HasTime() {
+			return false
+		}
+		if !f.Since.IsZero() && e.Time.Before(f.Since) {
+			return false
+		}
+		if !f.Until.IsZero() && e.Time.After(f.Until) {
+			return false
+		}
+	}
+
+	if f.Grep != nil && !f.Grep.MatchString(e.Message) {
+		return false
+	}
+
+	return true
+}
+
+// timestampLayouts are the line prefixes recognised as timestamps, longest first
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.000Z07:00",
+	time.RFC3339,
+	"2006-01-02T15:04:05.000",
+	"2006-01-02 15:04:05.000",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+}
+
+// ParseTimestamp extracts a leading timestamp from a log line, such as
+// "2024-01-02T15:04:05Z msg", "[2024-01-02 15:04:05] msg" or PM2's
+// "--time" prefix "2024-01-02T15:04:05: msg"
+func ParseTimestamp(line string) (time.Time, bool) {
+	s := strings.TrimPrefix(line, "[")
+	if len(s) < len("2006-01-02 15:04:05") || s[4] != '-' || s[7] != '-' {
+		return time.Time{}, false
+	}
+
+	// Scan the run of characters a timestamp can contain
+	end := 0
+	for end < len(s) {
+		c := s[end]
+		if (c >= '0' && c <= '9') || strings.IndexByte("-:.TZ+", c) >= 0 || (end == 10 && c == ' ') {
+			end++
+			continue
+		}
+		break
+	}
+	candidate := strings.TrimRight(s[:end], ":")
+
+	for _, layout := range timestampLayouts {
+		if t, err := time.ParseInLocation(layout, candidate, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// ParseTimeSpec parses a --since/--until value: an absolute time
+// ("2024-01-02", "2024-01-02 15:04:05", RFC3339) or a duration ago ("30m", "2h")
+func ParseTimeSpec(spec string) (time.Time, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return time.Time{}, nil
+	}
+	if spec == "now" {
+		return time.Now(), nil
+	}
+
+	if d, err := time.ParseDuration(strings.TrimSuffix(spec, " ago")); err == nil {
+		return time.Now().Add(-d), nil
+	}
+
+	for _, layout := range append(timestampLayouts, "2006-01-02") {
+		if t, err := time.ParseInLocation(layout, spec, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time '%s' (use e.g. 2024-01-02, \"2024-01-02 15:04:05\" or 30m)", spec)
+}
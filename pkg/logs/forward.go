@@ -0,0 +1,412 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sink delivers batches of log entries to a remote destination. Send
+// returns how many of the entries were delivered, in order, even when it
+// fails part way through the batch.
+type Sink interface {
+	Name() string
+	Send(entries []Entry) (int, error)
+	Close() error
+}
+
+// ForwardOptions controls buffering, batching and retries of a Forwarder
+type ForwardOptions struct {
+	BufferSize    int           // max entries held while the sink is unavailable
+	BatchSize     int           // max entries per Send call
+	FlushInterval time.Duration // how long to wait before sending a partial batch
+	MaxBackoff    time.Duration // upper bound for the retry delay
+}
+
+// DefaultForwardOptions returns the options used when none are configured
+func DefaultForwardOptions() ForwardOptions {
+	return ForwardOptions{
+		BufferSize:    10000,
+		BatchSize:     100,
+		FlushInterval: time.Second,
+		MaxBackoff:    30 * time.Second,
+	}
+}
+
+// ParseSink creates a sink from a destination spec:
+//
+//	syslog+udp://host:514   RFC 5424 syslog over UDP
+//	syslog+tcp://host:601   RFC 5424 syslog over TCP (octet-counted framing)
+//	tcp://host:5170         newline-delimited JSON over TCP
+//	http(s)://host/path     JSON array batches sent with POST
+func ParseSink(spec string) (Sink, error) {
+	u, err := url.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid destination '%s': %v", spec, err)
+	}
+
+	switch u.Scheme {
+	case "syslog", "syslog+udp":
+		return newSyslogSink("udp", hostWithPort(u.Host, "514")), nil
+	case "syslog+tcp":
+		return newSyslogSink("tcp", hostWithPort(u.Host, "601")), nil
+	case "tcp":
+		if u.Port() == "" {
+			return nil, fmt.Errorf("invalid destination '%s': port required", spec)
+		}
+		return &jsonTCPSink{address: u.Host}, nil
+	case "http", "https":
+		return &httpSink{url: spec, client: &http.Client{Timeout: 10 * time.Second}}, nil
+	}
+	return nil, fmt.Errorf("unsupported destination '%s' (use syslog+udp://, syslog+tcp://, tcp:// or http(s)://)", spec)
+}
+
+// hostWithPort adds a default port to a host when missing
+func hostWithPort(host, port string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// Forwarder buffers entries in memory and ships them to a sink in batches,
+// retrying with exponential backoff while the sink is unavailable
+type Forwarder struct {
+	sink     Sink
+	opts     ForwardOptions
+	mu       sync.Mutex
+	buffer   []Entry
+	inflight int // entries at the front of buffer currently being sent
+	dropped  int
+	notify   chan struct{}
+}
+
+// NewForwarder creates a forwarder for a sink
+func NewForwarder(sink Sink, opts ForwardOptions) *Forwarder {
+	defaults := DefaultForwardOptions()
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = defaults.BufferSize
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaults.BatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaults.FlushInterval
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = defaults.MaxBackoff
+	}
+
+	return &Forwarder{
+		sink:   sink,
+		opts:   opts,
+		notify: make(chan struct{}, 1),
+	}
+}
+
+// Push queues an entry without blocking. When the buffer is full the oldest
+// entry is dropped.
+func (f *Forwarder) Push(entry Entry) {
+	f.mu.Lock()
+	if len(f.buffer) >= f.opts.BufferSize {
+		f.dropped++
+		if len(f.buffer) == f.inflight {
+			// Everything buffered is being sent; drop the new entry instead
+			f.mu.Unlock()
+			return
+		}
+		// Drop the oldest entry that isn't part of the batch being sent
+		f.buffer = append(f.buffer[:f.inflight], f.buffer[f.inflight+1:]...)
+	}
+	f.buffer = append(f.buffer, entry)
+	full := len(f.buffer) >= f.opts.BatchSize
+	f.mu.Unlock()
+
+	if full {
+		select {
+		case f.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Dropped returns how many entries were discarded because the buffer was full
+func (f *Forwarder) Dropped() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.dropped
+}
+
+// Run sends buffered entries until stop is closed, then makes a final attempt
+// to deliver what is left and closes the sink
+func (f *Forwarder) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(f.opts.FlushInterval)
+	defer ticker.Stop()
+	defer f.sink.Close()
+
+	backoff := time.Duration(0)
+	for {
+		select {
+		case <-stop:
+			f.flush()
+			return
+		case <-ticker.C:
+		case <-f.notify:
+		}
+
+		for {
+			sent, err := f.sendBatch()
+			if err != nil {
+				// Keep the entries and wait before trying again
+				backoff = nextBackoff(backoff, f.opts.MaxBackoff)
+				fmt.Fprintf(os.Stderr, "Warning: forwarding to %s failed: %v (retrying in %s)\n", f.sink.Name(), err, backoff)
+				select {
+				case <-stop:
+					f.flush()
+					return
+				case <-time.After(backoff):
+				}
+				continue
+			}
+			backoff = 0
+			if sent < f.opts.BatchSize {
+				break
+			}
+		}
+	}
+}
+
+// flush makes one last attempt to send everything still buffered
+func (f *Forwarder) flush() {
+	for {
+		sent, err := f.sendBatch()
+		if err != nil || sent == 0 {
+			return
+		}
+	}
+}
+
+// sendBatch sends up to BatchSize buffered entries, removing the delivered ones
+func (f *Forwarder) sendBatch() (int, error) {
+	f.mu.Lock()
+	n := len(f.buffer)
+	if n > f.opts.BatchSize {
+		n = f.opts.BatchSize
+	}
+	batch := append([]Entry(nil), f.buffer[:n]...)
+	f.inflight = n
+	f.mu.Unlock()
+
+	if len(batch) == 0 {
+		return 0, nil
+	}
+	sent, err := f.sink.Send(batch)
+
+	// Delivered entries aren't sent again when the rest is retried
+	f.mu.Lock()
+	f.buffer = f.buffer[sent:]
+	f.inflight = 0
+	f.mu.Unlock()
+
+	if err != nil {
+		return 0, err
+	}
+
+	return sent, nil
+}
+
+// nextBackoff doubles the retry delay up to a maximum
+func nextBackoff(current, max time.Duration) time.Duration {
+	if current == 0 {
+		return 500 * time.Millisecond
+	}
+	current *= 2
+	if current > max {
+		return max
+	}
+	return current
+}
+
+// syslogSink sends RFC 5424 messages over UDP or TCP
+type syslogSink struct {
+	network  string
+	address  string
+	hostname string
+	conn     net.Conn
+}
+
+func newSyslogSink(network, address string) *syslogSink {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	return &syslogSink{network: network, address: address, hostname: hostname}
+}
+
+func (s *syslogSink) Name() string {
+	return "syslog+" + s.network + "://" + s.address
+}
+
+func (s *syslogSink) Send(entries []Entry) (int, error) {
+	if s.conn == nil {
+		conn, err := net.DialTimeout(s.network, s.address, 5*time.Second)
+		if err != nil {
+			return 0, err
+		}
+		s.conn = conn
+	}
+
+	for i, entry := range entries {
+		message := FormatRFC5424(entry, s.hostname)
+		if s.network == "tcp" {
+			// Octet-counting framing (RFC 6587)
+			message = fmt.Sprintf("%d %s", len(message), message)
+		}
+		s.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+		if _, err := s.conn.Write([]byte(message)); err != nil {
+			s.Close()
+			return i, err
+		}
+	}
+	return len(entries), nil
+}
+
+func (s *syslogSink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// FormatRFC5424 renders an entry as an RFC 5424 syslog message using the
+// "user" facility, with error output at severity "err" and the rest at "info"
+func FormatRFC5424(entry Entry, hostname string) string {
+	severity := 6
+	if entry.Stream == StreamErr {
+		severity = 3
+	}
+	priority := 1*8 + severity
+
+	timestamp := "-"
+	if entry.HasTime() {
+		timestamp = entry.Time.UTC().Format("2006-01-02T15:04:05.000000Z07:00")
+	}
+
+	return fmt.Sprintf("<%d>1 %s %s %s - %s [pm2go@32473 pm_id=\"%d\" stream=\"%s\"] %s",
+		priority, timestamp, syslogField(hostname, 255), syslogField(entry.App, 48),
+		syslogField(entry.Stream, 32), entry.ID, entry.Stream, entry.Message)
+}
+
+// syslogField restricts a header field to printable ASCII without spaces
+func syslogField(value string, maxLen int) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r > 32 && r < 127 {
+			b.WriteRune(r)
+		}
+	}
+	field := b.String()
+	if field == "" {
+		return "-"
+	}
+	if len(field) > maxLen {
+		field = field[:maxLen]
+	}
+	return field
+}
+
+// jsonTCPSink sends newline-delimited JSON over a TCP connection
+type jsonTCPSink struct {
+	address string
+	conn    net.Conn
+}
+
+func (s *jsonTCPSink) Name() string {
+	return "tcp://" + s.address
+}
+
+func (s *jsonTCPSink) Send(entries []Entry) (int, error) {
+	if s.conn == nil {
+		conn, err := net.DialTimeout("tcp", s.address, 5*time.Second)
+		if err != nil {
+			return 0, err
+		}
+		s.conn = conn
+	}
+
+	var buf bytes.Buffer
+	ends := make([]int, len(entries))
+	for i, entry := range entries {
+		buf.WriteString(FormatJSON(entry))
+		buf.WriteByte('\n')
+		ends[i] = buf.Len()
+	}
+
+	s.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	if written, err := s.conn.Write(buf.Bytes()); err != nil {
+		s.Close()
+		// Entries written in full were delivered
+		sent := 0
+		for sent < len(ends) && ends[sent] <= written {
+			sent++
+		}
+		return sent, err
+	}
+	return len(entries), nil
+}
+
+func (s *jsonTCPSink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// httpSink posts batches as a JSON array
+type httpSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s *httpSink) Name() string {
+	return s.url
+}
+
+// Send posts the batch as a whole, so it is either delivered or not at all
+func (s *httpSink) Send(entries []Entry) (int, error) {
+	batch := make([]json.RawMessage, 0, len(entries))
+	for _, entry := range entries {
+		batch = append(batch, json.RawMessage(FormatJSON(entry)))
+	}
+
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return 0, fmt.Errorf("server responded with %s", resp.Status)
+	}
+	return len(entries), nil
+}
+
+func (s *httpSink) Close() error {
+	return nil
+}
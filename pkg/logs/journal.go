@@ -0,0 +1,220 @@
+package logs
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JournalSource describes an application whose output goes to journald
+type JournalSource struct {
+	App         string
+	ID          int
+	Unit        string
+	UserMode    bool   // read the user journal (journalctl --user)
+	UID         string // with UserMode, read this user's journal instead (needs root)
+	AfterCursor string // only entries after this cursor (set by flush)
+}
+
+// journalRecord is the subset of journalctl --output=json fields we use
+type journalRecord struct {
+	Cursor   string          `json:"__CURSOR"`
+	Realtime string          `json:"__REALTIME_TIMESTAMP"`
+	Message  json.RawMessage `json:"MESSAGE"`
+	Unit     string          `json:"_SYSTEMD_UNIT"`
+	UserUnit string          `json:"_SYSTEMD_USER_UNIT"`
+}
+
+// journalArgs returns the common journalctl arguments for a set of sources.
+// Another user's units are matched by their fields, since --user only
+// reads the journal of the caller.
+func journalArgs(src JournalSource, units []string) []string {
+	args := []string{"--output=json", "--no-pager", "--all"}
+	if src.UserMode && src.UID != "" {
+		for _, unit := range units {
+			args = append(args, "_SYSTEMD_USER_UNIT="+unit+".service")
+		}
+		return append(args, "_UID="+src.UID)
+	}
+	if src.UserMode {
+		args = append(args, "--user")
+	}
+	for _, unit := range units {
+		args = append(args, "--unit", unit)
+	}
+	return args
+}
+
+// JournalTail returns the last n matching entries of a journal source.
+// A non-positive n returns every matching entry.
+func JournalTail(src JournalSource, n int, filter Filter) ([]Entry, error) {
+	args := journalArgs(src, []string{src.Unit})
+	if src.AfterCursor != "" {
+		args = append(args, "--after-cursor", src.AfterCursor)
+	}
+	if !filter.Since.IsZero() {
+		args = append(args, "--since", "@"+strconv.FormatInt(filter.Since.Unix(), 10))
+	}
+	if !filter.Until.IsZero() {
+		args = append(args, "--until", "@"+strconv.FormatInt(filter.Until.Unix()+1, 10))
+	}
+	// Let journalctl limit the output when no other filtering happens here
+	if n > 0 && filter.Grep == nil && !filter.Out && !filter.Err {
+		args = append(args, "--lines", strconv.Itoa(n))
+	}
+
+	output, err := exec.Command("journalctl", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("journalctl failed: %v", err)
+	}
+
+	var entries []Entry
+	for _, line := range strings.Split(string(output), "\n") {
+		entry, ok := parseJournalLine(line, src)
+		if !ok || !filter.Match(entry) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	if n > 0 && len(entries) > n {
+		entries = entries[len(entries)-n:]
+	}
+	return entries, nil
+}
+
+// LastJournalCursor returns the cursor of the newest journal entry of a source
+func LastJournalCursor(src JournalSource) (string, error) {
+	args := append(journalArgs(src, []string{src.Unit}), "--lines", "1")
+	output, err := exec.Command("journalctl", args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("journalctl failed: %v", err)
+	}
+
+	var record journalRecord
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(output))), &record); err != nil {
+		// No entries yet
+		return "", nil
+	}
+	return record.Cursor, nil
+}
+
+// FollowJournal streams new matching entries of the journal sources until
+// stop is closed. All sources must share the same journal (user or system).
+func FollowJournal(sources []JournalSource, filter Filter, stop <-chan struct{}, emit func(Entry)) error {
+	if len(sources) == 0 {
+		return nil
+	}
+
+	byUnit := make(map[string]JournalSource)
+	var units []string
+	for _, src := range sources {
+		byUnit[src.Unit] = src
+		units = append(units, src.Unit)
+	}
+
+	args := append(journalArgs(sources[0], units), "--follow", "--lines", "0")
+	cmd := exec.Command("journalctl", args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start journalctl: %v", err)
+	}
+
+	if stop != nil {
+		go func() {
+			<-stop
+			cmd.Process.Kill()
+		}()
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		var record journalRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		unit := record.UserUnit
+		if unit == "" {
+			unit = record.Unit
+		}
+		src, ok := byUnit[strings.TrimSuffix(unit, ".service")]
+		if !ok {
+			src, ok = byUnit[unit]
+		}
+		if !ok {
+			continue
+		}
+		if entry, ok := record.entry(src); ok && filter.Match(entry) {
+			emit(entry)
+		}
+	}
+
+	return cmd.Wait()
+}
+
+// parseJournalLine parses one line of journalctl --output=json
+func parseJournalLine(line string, src JournalSource) (Entry, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return Entry{}, false
+	}
+
+	var record journalRecord
+	if err := json.Unmarshal([]byte(line), &record); err != nil {
+		return Entry{}, false
+	}
+	return record.entry(src)
+}
+
+// entry converts a journal record into a log entry
+func (r journalRecord) entry(src JournalSource) (Entry, bool) {
+	message, ok := decodeJournalMessage(r.Message)
+	if !ok {
+		return Entry{}, false
+	}
+
+	// journald gets both streams of a unit at the same priority and can't
+	// tell them apart
+	entry := Entry{
+		App:     src.App,
+		ID:      src.ID,
+		Stream:  StreamCombined,
+		Message: message,
+	}
+
+	if usec, err := strconv.ParseInt(r.Realtime, 10, 64); err == nil {
+		entry.Time = time.UnixMicro(usec)
+	}
+
+	return entry, true
+}
+
+// decodeJournalMessage handles MESSAGE being a string or, for binary data, a byte array
+func decodeJournalMessage(raw json.RawMessage) (string, bool) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", false
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text, true
+	}
+
+	var bytes []byte
+	var ints []int
+	if err := json.Unmarshal(raw, &ints); err == nil {
+		for _, b := range ints {
+			bytes = append(bytes, byte(b))
+		}
+		return strings.TrimRight(string(bytes), "\n"), true
+	}
+	return "", false
+}
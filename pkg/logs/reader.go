@@ -0,0 +1,238 @@
+package logs
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Source describes a log file belonging to an application stream
+type Source struct {
+	App    string
+	ID     int
+	Stream string
+	Path   string
+}
+
+// pollInterval is how often followed files are checked for new data
+const pollInterval = 500 * time.Millisecond
+
+// maxLineSize bounds a single log line kept in memory
+const maxLineSize = 1024 * 1024
+
+// RotatedFiles returns the rotated siblings of a log file, oldest first.
+// Both logrotate ("app-out.log.1", "app-out.log.2.gz") and pm2-logrotate
+// ("app-out__2024-01-02_00-00-00.log") naming schemes are recognised.
+func RotatedFiles(path string) []string {
+	var candidates []string
+	if matches, err := filepath.Glob(path + ".*"); err == nil {
+		candidates = append(candidates, matches...)
+	}
+	if matches, err := filepath.Glob(strings.TrimSuffix(path, ".log") + "__*"); err == nil {
+		candidates = append(candidates, matches...)
+	}
+
+	type rotated struct {
+		path    string
+		modTime time.Time
+	}
+	var files []rotated
+	for _, candidate := range candidates {
+		info, err := os.Stat(candidate)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		files = append(files, rotated{path: candidate, modTime: info.ModTime()})
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+
+	result := make([]string, 0, len(files))
+	for _, file := range files {
+		result = append(result, file.path)
+	}
+	return result
+}
+
+// openLog opens a log file, transparently decompressing gzip files
+func openLog(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return file, nil
+	}
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{gz, file}, nil
+}
+
+// scanFile calls fn for every line of a log file. Lines without a timestamp
+// inherit the timestamp of the previous line (e.g. stack traces).
+func scanFile(src Source, path string, fn func(Entry)) error {
+	reader, err := openLog(path)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	var last time.Time
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		entry := newEntry(src, scanner.Text(), last)
+		last = entry.Time
+		fn(entry)
+	}
+	return scanner.Err()
+}
+
+// newEntry builds an entry for a line, falling back to the given timestamp
+func newEntry(src Source, line string, fallback time.Time) Entry {
+	entry := Entry{
+		App:     src.App,
+		ID:      src.ID,
+		Stream:  src.Stream,
+		Time:    fallback,
+		Message: line,
+	}
+	if t, ok := ParseTimestamp(line); ok {
+		entry.Time = t
+	}
+	return entry
+}
+
+// Tail returns the last n matching entries of a source, including its rotated
+// and compressed files. A non-positive n returns every matching entry.
+func Tail(src Source, n int, filter Filter) ([]Entry, error) {
+	var entries []Entry
+	collect := func(entry Entry) {
+		if !filter.Match(entry) {
+			return
+		}
+		entries = append(entries, entry)
+		if n > 0 && len(entries) > 2*n {
+			// Keep memory bounded on large files
+			entries = append(entries[:0], entries[len(entries)-n:]...)
+		}
+	}
+
+	for _, rotated := range RotatedFiles(src.Path) {
+		// Rotated files may have been removed meanwhile; skip them quietly
+		scanFile(src, rotated, collect)
+	}
+	if err := scanFile(src, src.Path, collect); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if n > 0 && len(entries) > n {
+		entries = entries[len(entries)-n:]
+	}
+	return entries, nil
+}
+
+// followState tracks the read position of a followed file
+type followState struct {
+	src     Source
+	info    os.FileInfo
+	offset  int64
+	partial string
+	last    time.Time
+}
+
+// Follow streams new matching entries from the sources until stop is closed.
+// Files are polled so that truncation (flush) and rotation are handled.
+func Follow(sources []Source, filter Filter, stop <-chan struct{}, emit func(Entry)) error {
+	states := make([]*followState, 0, len(sources))
+	for _, src := range sources {
+		state := &followState{src: src}
+		if info, err := os.Stat(src.Path); err == nil {
+			state.info = info
+			state.offset = info.Size()
+		}
+		states = append(states, state)
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, state := range states {
+			state.poll(filter, emit)
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll reads any data appended to the file since the last call
+func (s *followState) poll(filter Filter, emit func(Entry)) {
+	info, err := os.Stat(s.src.Path)
+	if err != nil {
+		return
+	}
+
+	// Start over when the file was replaced (rotation) or truncated (flush)
+	if s.info == nil || !os.SameFile(s.info, info) || info.Size() < s.offset {
+		s.offset = 0
+		s.partial = ""
+	}
+	s.info = info
+
+	if info.Size() == s.offset {
+		return
+	}
+
+	file, err := os.Open(s.src.Path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(s.offset, io.SeekStart); err != nil {
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, info.Size()-s.offset))
+	if err != nil {
+		return
+	}
+	s.offset += int64(len(data))
+
+	chunk := s.partial + string(data)
+	lines := strings.Split(chunk, "\n")
+
+	// The last element is an incomplete line (or empty after a newline)
+	s.partial = lines[len(lines)-1]
+	if len(s.partial) > maxLineSize {
+		lines = append(lines[:len(lines)-1], s.partial, "")
+		s.partial = ""
+	}
+
+	for _, line := range lines[:len(lines)-1] {
+		entry := newEntry(s.src, line, s.last)
+		s.last = entry.Time
+		if filter.Match(entry) {
+			emit(entry)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package systemd
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+)
+
+// LookupAccount resolves the user and group an app runs as. Either may be a
+// name or a numeric ID. Without a group the user's primary group is used;
+// uid or gid is -1 when neither names it.
+func LookupAccount(userName, groupName string) (int, int, error) {
+	uid, gid := -1, -1
+
+	if userName != "" {
+		u, err := user.Lookup(userName)
+		if err != nil {
+			if _, numeric := strconv.Atoi(userName); numeric != nil {
+				return -1, -1, fmt.Errorf("user '%s' does not exist", userName)
+			}
+			if u, err = user.LookupId(userName); err != nil {
+				return -1, -1, fmt.Errorf("user ID %s does not exist", userName)
+			}
+		}
+		uid, _ = strconv.Atoi(u.Uid)
+		gid, _ = strconv.Atoi(u.Gid)
+	}
+
+	if groupName != "" {
+		g, err := user.LookupGroup(groupName)
+		if err != nil {
+			if _, numeric := strconv.Atoi(groupName); numeric != nil {
+				return -1, -1, fmt.Errorf("group '%s' does not exist", groupName)
+			}
+			if g, err = user.LookupGroupId(groupName); err != nil {
+				return -1, -1, fmt.Errorf("group ID %s does not exist", groupName)
+			}
+		}
+		gid, _ = strconv.Atoi(g.Gid)
+	}
+
+	return uid, gid, nil
+}
+
+// checkAccount rejects a user or group that can't be used for an app
+func (m *Manager) checkAccount(config AppConfig) error {
+	if config.User == "" && config.Group == "" {
+		return nil
+	}
+	if m.userMode {
+		return fmt.Errorf("running an app as another user or group needs system mode (run pm2go as root)")
+	}
+	_, _, err := LookupAccount(config.User, config.Group)
+	return err
+}
+
+// serviceUser returns the User= of an app in system mode
+func (m *Manager) serviceUser(config AppConfig) string {
+	if config.User != "" {
+		return config.User
+	}
+	return m.getCurrentUser()
+}
+
+// chownLogFiles creates the log files of an app that runs as another user
+// or group and hands them over, so the app's logs stay readable and
+// flushable by their owner
+func (m *Manager) chownLogFiles(config AppConfig) error {
+	if config.User == "" && config.Group == "" || m.logBackend(config) == LogBackendJournal {
+		return nil
+	}
+
+	uid, gid, err := LookupAccount(config.User, config.Group)
+	if err != nil {
+		return err
+	}
+
+	config = substitutePMID(config)
+	outLog, errLog := m.resolveLogPaths(config, config.Cwd)
+
+	for _, path := range []string{outLog, errLog} {
+		if path == NullLogPath {
+			continue
+		}
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to create log file: %v", err)
+		}
+		file.Close()
+		if err := os.Chown(path, uid, gid); err != nil {
+			return fmt.Errorf("failed to change owner of %s: %v", path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,339 @@
+package systemd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/wojtekw92/pm2go/pkg/dotenv"
+)
+
+// envProfilePrefix marks PM2 environment profile blocks (env_production, ...)
+const envProfilePrefix = "env_"
+
+// envKeyPattern matches valid environment variable names
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// envFileKey lists dotenv files; it shares the env_ prefix but isn't a profile
+const envFileKey = "env_file"
+
+// inheritEnvKey selects the shell variables an app inherits
+const inheritEnvKey = "inherit_env"
+
+// argsKey holds the script arguments, as a list or a single string
+const argsKey = "args"
+
+// systemdKey holds extra unit directives by section
+const systemdKey = "systemd"
+
+// isEnvProfileKey reports whether an ecosystem key is an env_<profile> block
+func isEnvProfileKey(key string) bool {
+	return strings.HasPrefix(key, envProfilePrefix) && len(key) > len(envProfilePrefix) && key != envFileKey
+}
+
+// UnmarshalJSON decodes an ecosystem app entry, collecting env_<profile>
+// blocks and accepting non-string env values (PORT: 3000) like PM2 does
+func (c *AppConfig) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	envBlocks := make(map[string]json.RawMessage)
+	for key, value := range raw {
+		if key == "env" || isEnvProfileKey(key) {
+			envBlocks[key] = value
+			delete(raw, key)
+		}
+	}
+
+	// env_file may be a single path or a list of paths
+	envFiles, hasEnvFiles := raw[envFileKey]
+	delete(raw, envFileKey)
+
+	// PM2's uid and gid stand for user and group; numbers are IDs
+	for alias, key := range map[string]string{"uid": "user", "gid": "group"} {
+		value, ok := raw[alias]
+		delete(raw, alias)
+		if _, set := raw[key]; !ok || set {
+			continue
+		}
+		var id json.Number
+		if json.Unmarshal(value, &id) == nil {
+			value, _ = json.Marshal(id.String())
+		}
+		raw[key] = value
+	}
+
+	// inherit_env may be a policy string or a list of variable names
+	inheritEnv, hasInheritEnv := raw[inheritEnvKey]
+	delete(raw, inheritEnvKey)
+
+	// args may be a list or a string split like a shell command line
+	args, hasArgs := raw[argsKey]
+	delete(raw, argsKey)
+
+	// systemd sections hold directives with scalar values like env blocks
+	systemdBlock, hasSystemd := raw[systemdKey]
+	delete(raw, systemdKey)
+
+	rest, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+
+	// Decode the remaining fields without recursing into this method
+	type plainAppConfig AppConfig
+	var plain plainAppConfig
+	if err := json.Unmarshal(rest, &plain); err != nil {
+		return err
+	}
+	*c = AppConfig(plain)
+
+	if hasEnvFiles {
+		var single string
+		if err := json.Unmarshal(envFiles, &single); err == nil {
+			c.EnvFiles = []string{single}
+		} else if err := json.Unmarshal(envFiles, &c.EnvFiles); err != nil {
+			return fmt.Errorf("invalid %s: expected a path or a list of paths", envFileKey)
+		}
+	}
+
+	if hasArgs {
+		var line string
+		if err := json.Unmarshal(args, &line); err == nil {
+			if c.Args, err = SplitArgs(line); err != nil {
+				return err
+			}
+		} else if err := json.Unmarshal(args, &c.Args); err != nil {
+			return fmt.Errorf("invalid %s: expected a string or a list of strings", argsKey)
+		}
+	}
+
+	if hasInheritEnv {
+		var names []string
+		if err := json.Unmarshal(inheritEnv, &names); err == nil {
+			c.InheritEnv = strings.Join(names, ",")
+			if len(names) == 0 {
+				c.InheritEnv = InheritEnvNone
+			}
+		} else if err := json.Unmarshal(inheritEnv, &c.InheritEnv); err != nil {
+			return fmt.Errorf("invalid %s: expected %s, %s or a list of variable names", inheritEnvKey, InheritEnvAll, InheritEnvNone)
+		}
+	}
+
+	if hasSystemd {
+		var sections map[string]json.RawMessage
+		if err := json.Unmarshal(systemdBlock, &sections); err != nil {
+			return fmt.Errorf("invalid %s: expected an object of sections", systemdKey)
+		}
+		for section, value := range sections {
+			directives, err := decodeEnvBlock(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s.%s: %v", systemdKey, section, err)
+			}
+			for name, value := range directives {
+				c.Systemd.set(strings.ToLower(section), name, value)
+			}
+		}
+	}
+
+	for key, value := range envBlocks {
+		env, err := decodeEnvBlock(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %v", key, err)
+		}
+		if key == "env" {
+			c.Env = env
+			continue
+		}
+		if c.EnvProfiles == nil {
+			c.EnvProfiles = make(map[string]map[string]string)
+		}
+		c.EnvProfiles[strings.TrimPrefix(key, envProfilePrefix)] = env
+	}
+
+	return nil
+}
+
+// MarshalJSON encodes an app entry with its env_<profile> blocks
+func (c AppConfig) MarshalJSON() ([]byte, error) {
+	type plainAppConfig AppConfig
+	data, err := json.Marshal(plainAppConfig(c))
+	if err != nil || len(c.EnvProfiles) == 0 {
+		return data, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for profile, env := range c.EnvProfiles {
+		encoded, err := json.Marshal(env)
+		if err != nil {
+			return nil, err
+		}
+		fields[envProfilePrefix+profile] = encoded
+	}
+	return json.Marshal(fields)
+}
+
+// decodeEnvBlock decodes an env map, converting numbers and booleans to strings
+func decodeEnvBlock(data json.RawMessage) (map[string]string, error) {
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+
+	env := make(map[string]string, len(values))
+	for key, value := range values {
+		var text string
+		if err := json.Unmarshal(value, &text); err == nil {
+			env[key] = text
+			continue
+		}
+		if string(value) == "null" {
+			env[key] = ""
+			continue
+		}
+		// Numbers, booleans and nested values keep their JSON text
+		env[key] = string(value)
+	}
+	return env, nil
+}
+
+// ApplyEnvProfile merges the env_<profile> block over env and records the
+// profile as active. It reports whether the app defines the profile.
+func (c *AppConfig) ApplyEnvProfile(profile string) bool {
+	if profile == "" {
+		return false
+	}
+
+	values, ok := c.EnvProfiles[profile]
+	if !ok {
+		return false
+	}
+
+	merged := make(map[string]string, len(c.Env)+len(values))
+	for key, value := range c.Env {
+		merged[key] = value
+	}
+	c.Env = merged
+	for key, value := range values {
+		c.SetEnv(key, value, envProfilePrefix+profile)
+	}
+	c.EnvProfile = profile
+	return true
+}
+
+// SetEnv sets an environment variable and records where it came from
+func (c *AppConfig) SetEnv(key, value, source string) {
+	if c.Env == nil {
+		c.Env = make(map[string]string)
+	}
+	if c.EnvSources == nil {
+		c.EnvSources = make(map[string]string)
+	}
+	c.Env[key] = value
+	c.EnvSources[key] = source
+}
+
+// LoadEnvFiles reads the app's env files, resolving relative paths from
+// baseDir and storing absolute ones. With override set the files take
+// precedence over variables already in env, otherwise they only fill gaps.
+func (c *AppConfig) LoadEnvFiles(baseDir string, override bool) error {
+	for i, path := range c.EnvFiles {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		if absPath, err := filepath.Abs(path); err == nil {
+			path = absPath
+		}
+		c.EnvFiles[i] = path
+
+		vars, err := dotenv.Read(path)
+		if err != nil {
+			return fmt.Errorf("reading env file: %v", err)
+		}
+
+		keys := make([]string, 0, len(vars))
+		for key := range vars {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			// Later files override earlier ones
+			_, exists := c.Env[key]
+			if exists && !override && !strings.HasPrefix(c.EnvSource(key), EnvFileSource("")) {
+				continue
+			}
+			c.SetEnv(key, vars[key], EnvFileSource(path))
+		}
+	}
+	return nil
+}
+
+// EnvSource returns where an environment variable came from
+func (c *AppConfig) EnvSource(key string) string {
+	if source, ok := c.EnvSources[key]; ok {
+		return source
+	}
+	if c.Ecosystem != "" {
+		return EnvSourceEcosystem
+	}
+	return EnvSourceShell
+}
+
+// EnvProfileNames returns the names of the app's env profiles, sorted
+func (c *AppConfig) EnvProfileNames() []string {
+	names := make([]string, 0, len(c.EnvProfiles))
+	for name := range c.EnvProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateInheritEnv checks an inherit_env policy: all, none or a comma
+// separated list of variable names
+func ValidateInheritEnv(policy string) error {
+	if policy == "" || policy == InheritEnvAll || policy == InheritEnvNone {
+		return nil
+	}
+	for _, key := range strings.Split(policy, ",") {
+		if !envKeyPattern.MatchString(strings.TrimSpace(key)) {
+			return fmt.Errorf("invalid shell environment policy '%s' (expected %s, %s or a list of variable names)", policy, InheritEnvAll, InheritEnvNone)
+		}
+	}
+	return nil
+}
+
+// InheritShellEnv copies the variables of environ ("KEY=VALUE" entries)
+// allowed by policy into env. Variables that are already set are kept.
+func (c *AppConfig) InheritShellEnv(environ []string, policy string) {
+	if policy == InheritEnvNone {
+		return
+	}
+
+	var allowed map[string]bool
+	if policy != InheritEnvAll {
+		allowed = make(map[string]bool)
+		for _, key := range strings.Split(policy, ",") {
+			allowed[strings.TrimSpace(key)] = true
+		}
+	}
+
+	for _, entry := range environ {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok || (allowed != nil && !allowed[key]) {
+			continue
+		}
+		if _, exists := c.Env[key]; exists {
+			continue
+		}
+		c.SetEnv(key, value, EnvSourceShell)
+	}
+}
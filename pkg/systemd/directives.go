@@ -0,0 +1,256 @@
+package systemd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// systemdSectionNames maps the sections of an ecosystem systemd block to
+// their unit file headers
+var systemdSectionNames = map[string]string{
+	"unit":    "Unit",
+	"service": "Service",
+	"install": "Install",
+}
+
+// systemdMarker precedes the directives of an app's systemd block in its
+// unit file, so they can be told apart from the ones pm2go generates
+const systemdMarker = "# Directives from the systemd section"
+
+// dropInNamePattern matches drop-in file names
+var dropInNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.@-]+$`)
+
+// Directive is a single unit file setting
+type Directive struct {
+	Section string // unit, service or install
+	Name    string
+	Value   string
+}
+
+// ParseDirective parses a "section.Name=value" setting
+func ParseDirective(spec string) (Directive, error) {
+	key, value, found := strings.Cut(spec, "=")
+	section, name, dotted := strings.Cut(key, ".")
+	if !found || !dotted {
+		return Directive{}, fmt.Errorf("invalid directive '%s' (expected section.Name=value)", spec)
+	}
+
+	directive := Directive{Section: strings.ToLower(section), Name: name, Value: value}
+	if err := ValidateSystemdSections(SystemdSections{directive.Section: {name: value}}); err != nil {
+		return Directive{}, err
+	}
+	return directive, nil
+}
+
+// ValidateSystemdSections checks the sections and directives of a systemd block
+func ValidateSystemdSections(sections SystemdSections) error {
+	for section, directives := range sections {
+		if _, ok := systemdSectionNames[section]; !ok {
+			return fmt.Errorf("unknown systemd section '%s' (expected unit, service or install)", section)
+		}
+		for name, value := range directives {
+			if !directivePattern.MatchString(name) {
+				return fmt.Errorf("invalid directive '%s' in systemd.%s", name, section)
+			}
+			if strings.Contains(value, "\n") {
+				return fmt.Errorf("directive %s in systemd.%s can't contain newlines", name, section)
+			}
+		}
+	}
+	return nil
+}
+
+// addSystemdSections appends an app's own directives to the end of their
+// sections, so they take precedence over the ones pm2go generates
+func addSystemdSections(service string, sections SystemdSections) string {
+	for _, section := range []string{"unit", "service", "install"} {
+		if directives := sections[section]; len(directives) > 0 {
+			service = appendToSection(service, systemdSectionNames[section], systemdMarker+"\n"+sandboxLines(directives))
+		}
+	}
+	return service
+}
+
+// set records a directive of a section
+func (s *SystemdSections) set(section, name, value string) {
+	if *s == nil {
+		*s = make(SystemdSections)
+	}
+	if (*s)[section] == nil {
+		(*s)[section] = make(map[string]string)
+	}
+	(*s)[section][name] = value
+}
+
+// dropInDir returns the drop-in directory of a unit. systemd merges the
+// *.conf files in it over the unit file, and pm2go never rewrites them.
+func (m *Manager) dropInDir(serviceName string) string {
+	return filepath.Join(m.getServiceDir(), serviceName+".service.d")
+}
+
+// dropIns returns the drop-in files of a unit in the order systemd applies them
+func (m *Manager) dropIns(serviceName string) []string {
+	files, _ := filepath.Glob(filepath.Join(m.dropInDir(serviceName), "*.conf"))
+	return files
+}
+
+// DropInPath returns the path of a named drop-in of an app
+func (m *Manager) DropInPath(identifier, name string) (string, error) {
+	serviceName, err := m.findServiceByIdentifier(identifier)
+	if err != nil {
+		return "", err
+	}
+	name = strings.TrimSuffix(name, ".conf")
+	if !dropInNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid drop-in name '%s'", name)
+	}
+	return filepath.Join(m.dropInDir(serviceName), name+".conf"), nil
+}
+
+// WriteDropIn installs a drop-in of an app and reloads systemd. A drop-in
+// without directives is removed instead.
+func (m *Manager) WriteDropIn(identifier, name, content string) (string, error) {
+	path, err := m.DropInPath(identifier, name)
+	if err != nil {
+		return "", err
+	}
+
+	hasDirectives, err := CheckDropIn(content)
+	if err != nil {
+		return "", err
+	}
+	if !hasDirectives {
+		if err := m.removeFile(path, false); err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		if !m.DryRun() {
+			os.Remove(filepath.Dir(path)) // only succeeds once it is empty
+		}
+	} else {
+		if err := m.mkdirAll(filepath.Dir(path), 0755); err != nil {
+			return "", fmt.Errorf("failed to create %s: %v", filepath.Dir(path), err)
+		}
+		if err := m.writeFile(path, []byte(content), 0644); err != nil {
+			return "", fmt.Errorf("failed to write drop-in: %v", err)
+		}
+	}
+
+	if err := m.systemdReload(); err != nil {
+		return path, fmt.Errorf("failed to reload systemd: %v", err)
+	}
+	return path, nil
+}
+
+// SetDropInDirectives sets directives in a drop-in of an app. A directive
+// replaces earlier values of the same name in its section; repeating it in
+// directives adds further values (e.g. "ExecStart=" followed by a command).
+func (m *Manager) SetDropInDirectives(identifier, name string, directives []Directive) (string, error) {
+	path, err := m.DropInPath(identifier, name)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	content := string(data)
+
+	seen := make(map[string]bool)
+	for _, directive := range directives {
+		key := directive.Section + "." + directive.Name
+		content = setDirective(content, systemdSectionNames[directive.Section], directive.Name, directive.Value, !seen[key])
+		seen[key] = true
+	}
+	return m.WriteDropIn(identifier, name, content)
+}
+
+// RemoveDropIn removes a drop-in of an app and reloads systemd
+func (m *Manager) RemoveDropIn(identifier, name string) (string, error) {
+	path, err := m.DropInPath(identifier, name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return "", fmt.Errorf("drop-in %s does not exist", path)
+	}
+	if err := m.removeFile(path, false); err != nil {
+		return "", err
+	}
+	if !m.DryRun() {
+		os.Remove(filepath.Dir(path))
+	}
+
+	if err := m.systemdReload(); err != nil {
+		return path, fmt.Errorf("failed to reload systemd: %v", err)
+	}
+	return path, nil
+}
+
+// CheckDropIn reports whether a drop-in sets any directives, and rejects
+// directives outside a section or lines that aren't directives
+func CheckDropIn(content string) (bool, error) {
+	hasDirectives, inSection := false, false
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			inSection = true
+		case !inSection:
+			return false, fmt.Errorf("line %d: directive outside of a [Section]", i+1)
+		case !strings.Contains(line, "=") || !directivePattern.MatchString(strings.TrimSpace(strings.SplitN(line, "=", 2)[0])):
+			return false, fmt.Errorf("line %d: expected Name=value, got '%s'", i+1, line)
+		default:
+			hasDirectives = true
+		}
+	}
+	return hasDirectives, nil
+}
+
+// setDirective sets a directive in a section of unit file content, adding
+// the section when it is missing. With replace, earlier values of the
+// directive in that section are dropped.
+func setDirective(content, section, name, value string, replace bool) string {
+	var lines []string
+	if content != "" {
+		lines = strings.Split(strings.TrimRight(content, "\n"), "\n")
+	}
+
+	header := "[" + section + "]"
+	current, end := "", -1
+	var out []string
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			if current == header {
+				end = len(out)
+			}
+			current = trimmed
+		} else if replace && current == header && strings.HasPrefix(trimmed, name+"=") {
+			continue
+		}
+		out = append(out, line)
+	}
+	if current == header {
+		end = len(out)
+	}
+
+	entry := name + "=" + value
+	if end < 0 {
+		if len(out) > 0 {
+			out = append(out, "")
+		}
+		out = append(out, header, entry)
+	} else {
+		// Keep the blank lines before the next section after the new entry
+		for end > 0 && strings.TrimSpace(out[end-1]) == "" {
+			end--
+		}
+		out = append(out[:end], append([]string{entry}, out[end:]...)...)
+	}
+	return strings.Join(out, "\n") + "\n"
+}
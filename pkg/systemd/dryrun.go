@@ -0,0 +1,69 @@
+package systemd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// SetDryRun makes the manager describe on w the files it would write and the
+// systemctl commands it would run instead of doing so. A nil w turns it off.
+func (m *Manager) SetDryRun(w io.Writer) {
+	m.dryRun = w
+}
+
+// DryRun reports whether changes are only described
+func (m *Manager) DryRun() bool {
+	return m.dryRun != nil
+}
+
+// dryRunf describes a change skipped in dry-run mode
+func (m *Manager) dryRunf(format string, args ...interface{}) {
+	fmt.Fprintf(m.dryRun, format, args...)
+}
+
+// runSystemctl runs a systemctl command that changes state, or prints it in
+// dry-run mode
+func (m *Manager) runSystemctl(args ...string) error {
+	cmd := m.systemctl(args...)
+	if m.DryRun() {
+		m.dryRunf("Would run: %s\n", strings.Join(cmd.Args, " "))
+		return nil
+	}
+	return cmd.Run()
+}
+
+// removeFile removes a file or, with all, a directory tree. Dry-run mode
+// only mentions paths that exist.
+func (m *Manager) removeFile(path string, all bool) error {
+	if m.DryRun() {
+		if _, err := os.Stat(path); err == nil {
+			m.dryRunf("Would remove %s\n", path)
+		}
+		return nil
+	}
+	if all {
+		return os.RemoveAll(path)
+	}
+	return os.Remove(path)
+}
+
+// truncateFile empties a file. Dry-run mode only mentions files that exist.
+func (m *Manager) truncateFile(path string) error {
+	if m.DryRun() {
+		if _, err := os.Stat(path); err == nil {
+			m.dryRunf("Would truncate %s\n", path)
+		}
+		return nil
+	}
+	return os.Truncate(path, 0)
+}
+
+// reserveDryRunID keeps IDs handed out in dry-run mode from being handed out
+// again, since no unit records them
+func (m *Manager) reserveDryRunID(id int) {
+	if m.DryRun() && id >= m.dryRunNextID {
+		m.dryRunNextID = id + 1
+	}
+}
@@ -0,0 +1,141 @@
+package systemd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// envFileEscaper escapes the characters that are special inside a double
+// quoted EnvironmentFile= value. Newlines are kept as they are.
+var envFileEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "`", "\\`")
+
+// envFilePath returns where the environment of a unit is stored. The unit
+// file itself is world-readable, so values are kept out of it.
+func (m *Manager) envFilePath(serviceName string) string {
+	return filepath.Join(m.stateDir(), "env", serviceName+".env")
+}
+
+// generateEnvironmentFile renders env in EnvironmentFile= syntax, sorted so
+// regenerated files can be compared with the installed ones
+func generateEnvironmentFile(env map[string]string) string {
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		fmt.Fprintf(&b, "%s=\"%s\"\n", key, envFileEscaper.Replace(env[key]))
+	}
+	return b.String()
+}
+
+// parseEnvironmentFile reads the KEY="value" entries written by
+// generateEnvironmentFile; quoted values may span several lines
+func parseEnvironmentFile(data string) map[string]string {
+	env := make(map[string]string)
+	for len(data) > 0 {
+		line, rest, _ := strings.Cut(data, "\n")
+		key, value, found := strings.Cut(line, "=")
+		if !found || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			data = rest
+			continue
+		}
+
+		if !strings.HasPrefix(value, `"`) {
+			env[strings.TrimSpace(key)] = strings.TrimSpace(value)
+			data = rest
+			continue
+		}
+
+		// Scan the quoted value from just after the opening quote
+		data = data[len(key)+2:]
+		var b strings.Builder
+		i := 0
+		for ; i < len(data) && data[i] != '"'; i++ {
+			if data[i] == '\\' && i+1 < len(data) {
+				i++
+			}
+			b.WriteByte(data[i])
+		}
+		env[strings.TrimSpace(key)] = b.String()
+
+		// Skip the closing quote and the rest of its line
+		data = data[min(i+1, len(data)):]
+		_, data, _ = strings.Cut(data, "\n")
+	}
+	return env
+}
+
+// writeEnvironmentFile writes the environment of a unit so only its owner
+// can read it, or removes the file when env is empty
+func (m *Manager) writeEnvironmentFile(serviceName string, env map[string]string) error {
+	path := m.envFilePath(serviceName)
+	if len(env) == 0 {
+		if err := m.removeFile(path, false); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if m.DryRun() {
+		// Values stay out of the output like they stay out of the unit
+		m.dryRunf("Would write %s with %d variables\n", path, len(env))
+		return nil
+	}
+
+	if err := m.mkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %v", filepath.Dir(path), err)
+	}
+	if err := m.writeFile(path, []byte(generateEnvironmentFile(env)), 0600); err != nil {
+		return fmt.Errorf("failed to write environment file: %v", err)
+	}
+	// WriteFile keeps the mode of an existing file
+	return os.Chmod(path, 0600)
+}
+
+// writeServiceFiles writes the unit file of an app and its environment file
+func (m *Manager) writeServiceFiles(serviceName string, config AppConfig) error {
+	service, err := m.generateServiceFile(config)
+	if err != nil {
+		return err
+	}
+
+	if err := m.writeEnvironmentFile(serviceName, substitutePMID(config).Env); err != nil {
+		return err
+	}
+
+	servicePath := filepath.Join(m.getServiceDir(), serviceName+".service")
+	if m.DryRun() {
+		m.dryRunf("Would write %s:\n%s\n", servicePath, service)
+		return nil
+	}
+
+	if err := m.createLogDirs(config); err != nil {
+		return err
+	}
+	if err := m.chownLogFiles(config); err != nil {
+		return err
+	}
+
+	if err := m.writeFile(servicePath, []byte(service), 0644); err != nil {
+		return fmt.Errorf("failed to write service file: %v", err)
+	}
+	return nil
+}
+
+// removeServiceFiles removes the unit file of an app, its drop-ins and its
+// environment file
+func (m *Manager) removeServiceFiles(serviceName string) {
+	m.removeFile(filepath.Join(m.getServiceDir(), serviceName+".service"), false)
+	m.removeFile(m.dropInDir(serviceName), true)
+	m.removeFile(m.envFilePath(serviceName), false)
+}
+
+// FormatEnvironmentFile renders env in EnvironmentFile= syntax
+func FormatEnvironmentFile(env map[string]string) string {
+	return generateEnvironmentFile(env)
+}
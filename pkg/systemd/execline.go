@@ -0,0 +1,253 @@
+package systemd
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// execEscaper escapes the characters that are special in a quoted
+// ExecStart= word. '$' and '%' are doubled so systemd passes them on
+// instead of expanding variables and specifiers.
+var execEscaper = strings.NewReplacer(
+	`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`,
+	"$", "$$", "%", "%%",
+)
+
+// QuoteExecArg formats a value as a single ExecStart= word. Values without
+// whitespace, quotes or backslashes are left unquoted.
+func QuoteExecArg(value string) string {
+	if value == ";" {
+		// A lone semicolon would separate commands
+		return `\;`
+	}
+	if value != "" && !strings.ContainsAny(value, " \t\n\r\"'\\") && !hasControlChars(value) {
+		return strings.NewReplacer("$", "$$", "%", "%%").Replace(value)
+	}
+
+	escaped := execEscaper.Replace(value)
+	var b strings.Builder
+	for _, r := range escaped {
+		if r < 0x20 || r == 0x7f {
+			fmt.Fprintf(&b, `\x%02x`, r)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return `"` + b.String() + `"`
+}
+
+// hasControlChars reports whether a value contains ASCII control characters
+func hasControlChars(value string) bool {
+	for _, r := range value {
+		if r < 0x20 || r == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+// ExecArgv returns the command line of an app: its interpreter, when it has
+// or needs one, followed by the script and its arguments
+func ExecArgv(config AppConfig) []string {
+	var argv []string
+	if config.Interpreter != "" {
+		// The interpreter may come with flags, split like a shell would
+		interpreter, err := SplitArgs(config.Interpreter)
+		if err != nil {
+			interpreter = strings.Fields(config.Interpreter)
+		}
+		argv = interpreter
+	} else if strings.HasSuffix(config.Script, ".py") {
+		argv = []string{"python3"}
+	} else if strings.HasSuffix(config.Script, ".js") {
+		argv = []string{"node"}
+	}
+	// Other scripts run directly (should have shebang)
+	argv = append(argv, config.Script)
+	return append(argv, config.Args...)
+}
+
+// resolveInterpreter returns the full path of an interpreter, or its name
+// when it isn't in PATH
+func resolveInterpreter(name string) string {
+	if path, err := exec.LookPath(name); err == nil {
+		return path
+	}
+	if name == "node" {
+		if path, err := exec.LookPath("nodejs"); err == nil {
+			return path
+		}
+	}
+	return name
+}
+
+// FormatExecLine joins a command and its arguments into an ExecStart= value
+func FormatExecLine(argv []string) string {
+	words := make([]string, len(argv))
+	for i, arg := range argv {
+		words[i] = QuoteExecArg(arg)
+	}
+	return strings.Join(words, " ")
+}
+
+// ParseExecLine splits an ExecStart= value into the command and its
+// arguments, undoing the quoting and escaping of FormatExecLine
+func ParseExecLine(line string) []string {
+	var argv []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\' && i+1 < len(runes):
+			i++
+			inWord = true
+			switch next := runes[i]; next {
+			case 'n':
+				word.WriteRune('\n')
+			case 't':
+				word.WriteRune('\t')
+			case 'r':
+				word.WriteRune('\r')
+			case 'x':
+				if i+2 < len(runes) {
+					if code, err := strconv.ParseUint(string(runes[i+1:i+3]), 16, 8); err == nil {
+						word.WriteByte(byte(code))
+						i += 2
+						continue
+					}
+				}
+				word.WriteRune(next)
+			default:
+				word.WriteRune(next)
+			}
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+			inWord = true
+		case quote == 0 && (r == ' ' || r == '\t'):
+			if inWord {
+				argv = append(argv, unescapeExecWord(word.String()))
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		argv = append(argv, unescapeExecWord(word.String()))
+	}
+	return argv
+}
+
+// parseExecWord undoes QuoteExecArg
+func parseExecWord(word string) string {
+	if argv := ParseExecLine(word); len(argv) > 0 {
+		return argv[0]
+	}
+	return ""
+}
+
+// escapeSpecifiers doubles '%' so systemd doesn't expand specifiers in a
+// path directive such as WorkingDirectory=
+func escapeSpecifiers(value string) string {
+	return strings.ReplaceAll(value, "%", "%%")
+}
+
+// unescapeSpecifiers undoes escapeSpecifiers
+func unescapeSpecifiers(value string) string {
+	return strings.ReplaceAll(value, "%%", "%")
+}
+
+// unescapeExecWord undoes the doubling of '$' and '%' in an ExecStart= word
+func unescapeExecWord(value string) string {
+	return strings.NewReplacer("$$", "$", "%%", "%").Replace(value)
+}
+
+// quotePathWord formats a path as one word of a space-separated path list
+// such as ReadWritePaths=
+func quotePathWord(path string) string {
+	path = escapeSpecifiers(path)
+	if !strings.ContainsAny(path, " \t\"'\\") {
+		return path
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(path) + `"`
+}
+
+// SplitArgs splits a PM2-style args string into arguments like a shell:
+// words are separated by whitespace, single quotes keep text as it is and
+// double quotes allow \" and \\ escapes
+func SplitArgs(args string) ([]string, error) {
+	var argv []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+
+	runes := []rune(args)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\' && i+1 < len(runes) && (quote == 0 || strings.ContainsRune(`"\$`+"`", runes[i+1])):
+			i++
+			word.WriteRune(runes[i])
+			inWord = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				argv = append(argv, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in args", quote)
+	}
+	if inWord {
+		argv = append(argv, word.String())
+	}
+	return argv, nil
+}
+
+// JoinArgs formats arguments as a shell command line, quoting those with
+// characters the shell would interpret. SplitArgs splits it back into the
+// same arguments.
+func JoinArgs(args []string) string {
+	words := make([]string, len(args))
+	for i, arg := range args {
+		if arg != "" && strings.Trim(arg, shellSafeChars) == "" {
+			words[i] = arg
+			continue
+		}
+		words[i] = "'" + strings.ReplaceAll(arg, "'", `'"'"'`) + "'"
+	}
+	return strings.Join(words, " ")
+}
+
+// shellSafeChars never need quoting in a shell word
+const shellSafeChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_@%+=:,./-"
@@ -0,0 +1,301 @@
+package systemd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// jobPrefix follows the service prefix in the names of transient job
+// units: pm2-job-<id>-<name>
+const jobPrefix = "job-"
+
+// ExecModeJob is the exec mode of running jobs in process lists
+const ExecModeJob = "job"
+
+// maxJobHistory is the number of finished jobs kept in the history
+const maxJobHistory = 100
+
+// Job is a one-off command run as a transient unit
+type Job struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Unit       string     `json:"unit"`
+	Scope      string     `json:"scope"`
+	Command    []string   `json:"command"`
+	Cwd        string     `json:"cwd"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	ExitCode   int        `json:"exit_code"`
+}
+
+// JobLabel shows a job ID apart from app IDs, as j<id>
+func JobLabel(id int) string {
+	return "j" + strconv.Itoa(id)
+}
+
+// Status describes the outcome of a job: running, succeeded or failed
+func (j Job) Status() string {
+	switch {
+	case j.FinishedAt == nil:
+		return "running"
+	case j.ExitCode == 0:
+		return "succeeded"
+	}
+	return "failed"
+}
+
+// Duration returns how long a job ran, or has been running
+func (j Job) Duration() time.Duration {
+	if j.FinishedAt == nil {
+		return time.Since(j.StartedAt)
+	}
+	return j.FinishedAt.Sub(j.StartedAt)
+}
+
+// jobHistoryPath returns the file that records the jobs of the managed user
+func (m *Manager) jobHistoryPath() string {
+	return filepath.Join(m.stateDir(), "jobs.json")
+}
+
+// JobHistory returns the recorded jobs, oldest first
+func (m *Manager) JobHistory() ([]Job, error) {
+	data, err := os.ReadFile(m.jobHistoryPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []Job
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return nil, fmt.Errorf("invalid job history %s: %v", m.jobHistoryPath(), err)
+	}
+	return jobs, nil
+}
+
+// updateJobHistory changes the job history under a lock, so concurrent runs
+// don't lose each other's entries
+func (m *Manager) updateJobHistory(update func(jobs []Job) []Job) error {
+	path := m.jobHistoryPath()
+	if err := m.mkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		return err
+	}
+	if err := m.chown(path); err != nil {
+		return err
+	}
+
+	var jobs []Job
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &jobs); err != nil {
+			return fmt.Errorf("invalid job history %s: %v", path, err)
+		}
+	}
+
+	jobs = update(jobs)
+	if len(jobs) > maxJobHistory {
+		jobs = jobs[len(jobs)-maxJobHistory:]
+	}
+	data, err = json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := file.Truncate(0); err != nil {
+		return err
+	}
+	_, err = file.WriteAt(append(data, '\n'), 0)
+	return err
+}
+
+// RunJob runs a command as a transient unit with the terminal attached,
+// waits for it and returns the job with its exit code. A signal on interrupt
+// stops the job. The job is recorded in the history; it leaves no unit file
+// behind.
+func (m *Manager) RunJob(config AppConfig, interrupt <-chan os.Signal, stdin io.Reader, stdout, stderr io.Writer) (Job, error) {
+	if strings.ContainsAny(config.Name, "/ \t") || config.Name == "" {
+		return Job{}, fmt.Errorf("invalid job name '%s'", config.Name)
+	}
+	if err := m.checkAccount(config); err != nil {
+		return Job{}, err
+	}
+
+	cwd := config.Cwd
+	argv := ExecArgv(config)
+	// systemd-run needs the full path of the program
+	if strings.Contains(argv[0], "/") {
+		if !filepath.IsAbs(argv[0]) {
+			argv[0] = filepath.Join(cwd, argv[0])
+		}
+	} else {
+		argv[0] = resolveInterpreter(argv[0])
+	}
+
+	job := Job{Name: config.Name, Scope: m.Scope(), Command: argv, Cwd: cwd, StartedAt: time.Now()}
+	nextID := func(jobs []Job) {
+		for _, previous := range jobs {
+			if previous.ID >= job.ID {
+				job.ID = previous.ID + 1
+			}
+		}
+		job.Unit = m.prefix + jobPrefix + strconv.Itoa(job.ID) + "-" + job.Name
+	}
+	var err error
+	if m.DryRun() {
+		var jobs []Job
+		jobs, err = m.JobHistory()
+		nextID(jobs)
+	} else {
+		err = m.updateJobHistory(func(jobs []Job) []Job {
+			nextID(jobs)
+			return append(jobs, job)
+		})
+	}
+	if err != nil {
+		return Job{}, fmt.Errorf("failed to record job: %v", err)
+	}
+
+	args := append(m.scopeArgs(),
+		"--unit="+job.Unit,
+		"--description=PM2 Job: "+job.Name,
+		"--working-directory="+cwd,
+		"--wait", "--pipe", "--collect", "--quiet",
+	)
+	if !m.userMode {
+		args = append(args, "--uid="+m.serviceUser(config))
+		if config.Group != "" {
+			args = append(args, "--gid="+config.Group)
+		}
+	}
+
+	// Environment values stay out of the command line like out of units
+	if len(config.Env) > 0 {
+		if err := m.writeEnvironmentFile(job.Unit, config.Env); err != nil {
+			return job, err
+		}
+		defer m.removeFile(m.envFilePath(job.Unit), false)
+		args = append(args, "--property=EnvironmentFile="+m.envFilePath(job.Unit))
+	}
+	args = append(append(args, "--"), argv...)
+
+	run := exec.Command("systemd-run", args...)
+	if m.DryRun() {
+		m.dryRunf("Would run: %s\n", strings.Join(run.Args, " "))
+		return job, nil
+	}
+	run.Stdin, run.Stdout, run.Stderr = stdin, stdout, stderr
+
+	// systemd-run passes on the exit code of the job
+	err = run.Start()
+	if err == nil {
+		done := make(chan error, 1)
+		go func() { done <- run.Wait() }()
+	wait:
+		for {
+			select {
+			case err = <-done:
+				break wait
+			case <-interrupt:
+				m.systemdCommand("stop", job.Unit)
+			}
+		}
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		job.ExitCode = exitErr.ExitCode()
+		if job.ExitCode < 0 {
+			job.ExitCode = 128 + int(syscall.SIGINT)
+		}
+	default:
+		job.ExitCode = 1
+	}
+
+	// systemd-run may have died without taking the job with it
+	if m.getServiceStatus(job.Unit) == "online" {
+		m.systemdCommand("stop", job.Unit)
+	}
+
+	finished := time.Now()
+	job.FinishedAt = &finished
+	if historyErr := m.updateJobHistory(func(jobs []Job) []Job {
+		for i := range jobs {
+			if jobs[i].ID == job.ID {
+				jobs[i] = job
+			}
+		}
+		return jobs
+	}); historyErr != nil && err == nil {
+		err = fmt.Errorf("failed to record job: %v", historyErr)
+	}
+
+	if err != nil && exitErr == nil {
+		return job, fmt.Errorf("failed to run systemd-run: %v", err)
+	}
+	return job, nil
+}
+
+// RunningJobs returns the jobs of the managed scope that are still running,
+// as processes with the job exec mode
+func (m *Manager) RunningJobs() ([]ProcessInfo, error) {
+	jobs, err := m.JobHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	var processes []ProcessInfo
+	for _, job := range jobs {
+		if job.FinishedAt != nil || job.Scope != m.Scope() {
+			continue
+		}
+		status := m.getServiceStatus(job.Unit)
+		if status != "online" {
+			continue
+		}
+
+		pid := m.getServicePID(job.Unit)
+		process := ProcessInfo{
+			PID:  pid,
+			Name: job.Name,
+			PM2Env: PM2Env{
+				ID:          job.ID,
+				Name:        job.Name,
+				ExecMode:    ExecModeJob,
+				Status:      status,
+				PMUptime:    job.Duration().Milliseconds(),
+				CreatedAt:   job.StartedAt.UnixMilli(),
+				Cwd:         job.Cwd,
+				Scope:       job.Scope,
+				Interpreter: job.Command[0],
+				Args:        job.Command[1:],
+			},
+			Monit: PM2Monit{
+				Memory: m.getServiceMemory(pid),
+				CPU:    m.getServiceCPU(pid),
+			},
+		}
+		processes = append(processes, process)
+	}
+	return processes, nil
+}
@@ -2,18 +2,27 @@ package systemd
 
 import (
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
+	"os/user"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/wojtekw92/pm2go/pkg/logs"
 )
 
 // Manager handles systemd operations for PM2-style process management
 type Manager struct {
-	userMode bool
-	prefix   string // prefix for service names to avoid conflicts
+	userMode          bool
+	asUser            *user.User // another user whose apps root manages (--as)
+	prefix            string // prefix for service names to avoid conflicts
+	defaultLogBackend string // log backend for apps that don't set one
+	dryRun            io.Writer // describes changes instead of making them
+	dryRunNextID      int    // first ID not handed out in dry-run mode
 }
 
 // NewManager creates a new systemd manager instance
@@ -24,9 +33,9 @@ func NewManager() *Manager {
 	}
 }
 
-// serviceName returns the systemd service name for an app
-func (m *Manager) serviceName(appName string) string {
-	return m.prefix + appName
+// SetDefaultLogBackend sets the log backend used by apps that don't choose one
+func (m *Manager) SetDefaultLogBackend(backend string) {
+	m.defaultLogBackend = backend
 }
 
 // serviceNameWithID returns the systemd service name with ID for an app
@@ -77,6 +86,9 @@ func (m *Manager) getNextAvailableID() int {
 		}
 	}
 	
+	if m.dryRunNextID > maxID+1 {
+		return m.dryRunNextID
+	}
 	return maxID + 1
 }
 
@@ -130,22 +142,21 @@ func (m *Manager) Start(config AppConfig) error {
 		return err
 	}
 	
+	if err := m.checkConfig(config); err != nil {
+		return err
+	}
+	
 	// Assign ID if not set
 	if config.ID == 0 {
 		config.ID = m.getNextAvailableID()
 	}
+	m.reserveDryRunID(config.ID)
 	
 	serviceName := m.serviceNameWithID(config.ID, config.Name)
 
-	// Generate systemd service file content
-	serviceContent := m.generateServiceFile(config)
-
-	// Write service file
-	serviceDir := m.getServiceDir()
-	servicePath := filepath.Join(serviceDir, serviceName+".service")
-
-	if err := os.WriteFile(servicePath, []byte(serviceContent), 0644); err != nil {
-		return fmt.Errorf("failed to write service file: %v", err)
+	// Write the service file and its environment
+	if err := m.writeServiceFiles(serviceName, config); err != nil {
+		return err
 	}
 
 	// Reload systemd and start service
@@ -164,6 +175,125 @@ func (m *Manager) Start(config AppConfig) error {
 	return nil
 }
 
+// checkConfig rejects settings that would produce a broken unit
+func (m *Manager) checkConfig(config AppConfig) error {
+	if backend := m.logBackend(config); backend != LogBackendFile && backend != LogBackendJournal {
+		return fmt.Errorf("unknown log backend '%s' (expected '%s' or '%s')", backend, LogBackendFile, LogBackendJournal)
+	}
+	if err := ValidateSandbox(config.Sandbox, config.SandboxSet); err != nil {
+		return err
+	}
+	if err := ValidateSystemdSections(config.Systemd); err != nil {
+		return err
+	}
+	return m.checkAccount(config)
+}
+
+// Update regenerates the service file of an existing app and restarts it.
+// The app is identified by config.ID, which must belong to config.Name.
+func (m *Manager) Update(config AppConfig) error {
+	serviceName, err := m.findServiceByIdentifier(strconv.Itoa(config.ID))
+	if err != nil {
+		return err
+	}
+	if serviceName != m.serviceNameWithID(config.ID, config.Name) {
+		return fmt.Errorf("process with ID %d is not named '%s'", config.ID, config.Name)
+	}
+	
+	if err := m.checkConfig(config); err != nil {
+		return err
+	}
+	
+	if err := m.writeServiceFiles(serviceName, config); err != nil {
+		return err
+	}
+	
+	if err := m.systemdReload(); err != nil {
+		return fmt.Errorf("failed to reload systemd: %v", err)
+	}
+	
+	if err := m.systemdCommand("restart", serviceName); err != nil {
+		return fmt.Errorf("failed to restart service: %v", err)
+	}
+	
+	return nil
+}
+
+// GetAppConfig reconstructs the configuration of an app from its service file
+func (m *Manager) GetAppConfig(identifier string) (AppConfig, error) {
+	serviceName, err := m.findServiceByIdentifier(identifier)
+	if err != nil {
+		return AppConfig{}, err
+	}
+	
+	id, appName, err := m.parseServiceName(serviceName)
+	if err != nil {
+		return AppConfig{}, err
+	}
+	
+	service := m.readServiceConfig(serviceName)
+	config := AppConfig{
+		ID:          id,
+		Name:        appName,
+		Script:      service.Script,
+		Interpreter: service.Interpreter,
+		Cwd:         service.Cwd,
+		Args:        service.Args,
+		Env:         service.Env,
+		EnvProfile:  service.EnvProfile,
+		Ecosystem:   service.Ecosystem,
+		EnvFiles:    service.EnvFiles,
+		EnvSources:  service.EnvSources,
+		InheritEnv:  service.InheritEnv,
+		Sandbox:     service.Sandbox,
+		SandboxSet:  service.SandboxSet,
+		User:        service.AppUser,
+		Group:       service.AppGroup,
+		Systemd:     service.Systemd,
+		Template:    service.Template,
+	}
+	
+	if service.LogBackend == LogBackendJournal {
+		config.LogBackend = LogBackendJournal
+	} else if service.LogPath != "" {
+		config.LogFile = service.LogPath
+	} else {
+		// Only custom paths are kept; empty means the default location
+		defaultOut, defaultErr := m.resolveLogPaths(AppConfig{Name: appName}, "")
+		if service.OutLogPath != defaultOut {
+			config.OutFile = service.OutLogPath
+		}
+		if service.ErrLogPath != defaultErr {
+			config.ErrorFile = service.ErrLogPath
+		}
+		config.LogFile = service.LogFile
+	}
+	
+	return config, nil
+}
+
+// ServiceFileChanged reports whether config differs from the installed
+// service and environment files of the app with the same ID and name. It
+// only reads, so plans can use it.
+func (m *Manager) ServiceFileChanged(config AppConfig) (bool, error) {
+	serviceName := m.serviceNameWithID(config.ID, config.Name)
+	current, err := os.ReadFile(filepath.Join(m.serviceDir(), serviceName+".service"))
+	if err != nil {
+		return false, fmt.Errorf("failed to read service file: %v", err)
+	}
+	generated, err := m.generateServiceFile(config)
+	if err != nil {
+		return false, err
+	}
+	if string(current) != generated {
+		return true, nil
+	}
+	
+	// A missing environment file reads as empty
+	currentEnv, _ := os.ReadFile(m.envFilePath(serviceName))
+	return string(currentEnv) != generateEnvironmentFile(substitutePMID(config).Env), nil
+}
+
 // Stop stops a systemd service
 func (m *Manager) Stop(identifier string) error {
 	serviceName, err := m.findServiceByIdentifier(identifier)
@@ -182,6 +312,16 @@ func (m *Manager) Restart(id int) error {
 	return m.systemdCommand("restart", serviceName)
 }
 
+// Reload reloads an existing service by ID, restarting it when the unit
+// doesn't define a reload command
+func (m *Manager) Reload(id int) error {
+	serviceName, err := m.findServiceByIdentifier(strconv.Itoa(id))
+	if err != nil {
+		return err
+	}
+	return m.systemdCommand("reload-or-restart", serviceName)
+}
+
 // Delete stops and removes a systemd service
 func (m *Manager) Delete(identifier string) error {
 	if identifier == "all" {
@@ -198,10 +338,8 @@ func (m *Manager) Delete(identifier string) error {
 	m.systemdCommand("stop", serviceName)
 	m.systemdCommand("disable", serviceName)
 
-	// Remove service file
-	serviceDir := m.getServiceDir()
-	servicePath := filepath.Join(serviceDir, serviceName+".service")
-	os.Remove(servicePath)
+	// Remove service and environment files
+	m.removeServiceFiles(serviceName)
 
 	return m.systemdReload()
 }
@@ -217,10 +355,7 @@ func (m *Manager) deleteAll() error {
 		serviceName := m.serviceNameWithID(process.PM2Env.ID, process.Name)
 		m.systemdCommand("stop", serviceName)
 		m.systemdCommand("disable", serviceName)
-		
-		serviceDir := m.getServiceDir()
-		servicePath := filepath.Join(serviceDir, serviceName+".service")
-		os.Remove(servicePath)
+		m.removeServiceFiles(serviceName)
 	}
 	
 	return m.systemdReload()
@@ -277,13 +412,25 @@ func (m *Manager) List() ([]ProcessInfo, error) {
 				Node: PM2Node{
 					Version: "unknown",
 				},
-				PMExecPath:   config.Script,
-				PMOutLogPath: config.OutLogPath,
-				PMErrLogPath: config.ErrLogPath,
-				PMPidPath:    config.PidPath,
-				Interpreter:  config.Interpreter,
-				Args:         config.Args,
-				Env:          config.Env,
+				PMExecPath:    config.Script,
+				PMOutLogPath:  config.OutLogPath,
+				PMErrLogPath:  config.ErrLogPath,
+				PMLogPath:     config.LogPath,
+				LogBackend:    config.LogBackend,
+				Cwd:           config.Cwd,
+				EnvProfile:    config.EnvProfile,
+				EcosystemFile: config.Ecosystem,
+				InheritEnv:    config.InheritEnv,
+				Sandbox:       config.Sandbox,
+				Username:      config.User,
+				Group:         config.Group,
+				Scope:         m.Scope(),
+				DropIns:       m.dropIns(serviceName),
+				UnitTemplate:  m.unitTemplatePath(AppConfig{Template: config.Template}),
+				PMPidPath:     config.PidPath,
+				Interpreter:   config.ExecInterp,
+				Args:          config.Args,
+				Env:           config.Env,
 			},
 			Monit: PM2Monit{
 				Memory: memory,
@@ -299,7 +446,9 @@ func (m *Manager) List() ([]ProcessInfo, error) {
 // readServiceConfig reads and parses a systemd service file to extract configuration
 func (m *Manager) readServiceConfig(serviceName string) ServiceConfig {
 	config := ServiceConfig{
-		Env: make(map[string]string),
+		Env:        make(map[string]string),
+		EnvSources: make(map[string]string),
+		LogBackend: LogBackendFile,
 	}
 	
 	serviceDir := m.getServiceDir()
@@ -310,249 +459,546 @@ func (m *Manager) readServiceConfig(serviceName string) ServiceConfig {
 		return config
 	}
 	
+	// Directives from the app's systemd block follow a marker up to the
+	// end of their section
+	section, passthrough := "", false
+	var execArgv []string
+	recorded := false
 	lines := strings.Split(string(data), "\n")
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
+		switch {
+		case line == systemdMarker:
+			passthrough = true
+			continue
+		case line == "":
+			passthrough = false
+		case strings.HasPrefix(line, "["):
+			section = strings.ToLower(strings.Trim(line, "[]"))
+			passthrough = false
+		}
 		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
+		if passthrough {
+			name, value, _ := strings.Cut(line, "=")
+			config.Systemd.set(section, name, value)
+			continue
+		}
 		
 		if strings.HasPrefix(line, "ExecStart=") {
-			execStart := strings.TrimPrefix(line, "ExecStart=")
-			// Parse the exec command to extract script and interpreter
-			parts := strings.Fields(execStart)
-			if len(parts) > 0 {
-				config.Interpreter = parts[0]
-				if len(parts) > 1 {
-					config.Script = parts[1]
-					if len(parts) > 2 {
-						config.Args = strings.Join(parts[2:], " ")
-					}
+			execArgv = ParseExecLine(strings.TrimPrefix(line, "ExecStart="))
+		} else if strings.HasPrefix(line, metaScript+"=") {
+			config.Script = parseExecWord(strings.TrimPrefix(line, metaScript+"="))
+			recorded = true
+		} else if strings.HasPrefix(line, metaInterp+"=") {
+			config.Interpreter = parseExecWord(strings.TrimPrefix(line, metaInterp+"="))
+		} else if strings.HasPrefix(line, metaArgs+"=") {
+			config.Args = ParseExecLine(strings.TrimPrefix(line, metaArgs+"="))
+		} else if strings.HasPrefix(line, "EnvironmentFile=") {
+			data, err := os.ReadFile(strings.TrimPrefix(line, "EnvironmentFile="))
+			if err == nil {
+				for key, value := range parseEnvironmentFile(string(data)) {
+					config.Env[key] = value
 				}
 			}
 		} else if strings.HasPrefix(line, "Environment=") {
+			// Units written before environment files were used
 			envLine := strings.TrimPrefix(line, "Environment=")
 			// Parse environment variables
 			if strings.Contains(envLine, "=") {
 				parts := strings.SplitN(envLine, "=", 2)
 				if len(parts) == 2 {
-					// Remove quotes and unescape quoted quotes
-					value := strings.Trim(parts[1], "\"")
-					value = strings.ReplaceAll(value, "\\\"", "\"")
-					config.Env[parts[0]] = value
+					// Remove quotes and unescape the value
+					value := strings.TrimSuffix(strings.TrimPrefix(parts[1], "\""), "\"")
+					config.Env[parts[0]] = envValueUnescaper.Replace(value)
+				}
+			}
+		} else if strings.HasPrefix(line, "User=") {
+			config.User = strings.TrimPrefix(line, "User=")
+		} else if strings.HasPrefix(line, "Group=") {
+			config.Group = strings.TrimPrefix(line, "Group=")
+		} else if strings.HasPrefix(line, "WorkingDirectory=") {
+			config.Cwd = unescapeSpecifiers(strings.TrimPrefix(line, "WorkingDirectory="))
+		} else if strings.HasPrefix(line, metaEnvProfile+"=") {
+			config.EnvProfile = strings.TrimPrefix(line, metaEnvProfile+"=")
+		} else if strings.HasPrefix(line, metaEcosystem+"=") {
+			config.Ecosystem = strings.TrimPrefix(line, metaEcosystem+"=")
+		} else if strings.HasPrefix(line, metaSandbox+"=") {
+			config.Sandbox = strings.TrimPrefix(line, metaSandbox+"=")
+		} else if strings.HasPrefix(line, metaSandboxSet+"=") {
+			directive, value, _ := strings.Cut(strings.TrimPrefix(line, metaSandboxSet+"="), "=")
+			if config.SandboxSet == nil {
+				config.SandboxSet = make(map[string]string)
+			}
+			config.SandboxSet[directive] = value
+		} else if strings.HasPrefix(line, metaTemplate+"=") {
+			config.Template = strings.TrimPrefix(line, metaTemplate+"=")
+		} else if strings.HasPrefix(line, metaUser+"=") {
+			config.AppUser = strings.TrimPrefix(line, metaUser+"=")
+		} else if strings.HasPrefix(line, metaGroup+"=") {
+			config.AppGroup = strings.TrimPrefix(line, metaGroup+"=")
+		} else if strings.HasPrefix(line, metaLogFile+"=") {
+			config.LogFile = strings.TrimPrefix(line, metaLogFile+"=")
+		} else if strings.HasPrefix(line, metaInheritEnv+"=") {
+			config.InheritEnv = strings.TrimPrefix(line, metaInheritEnv+"=")
+		} else if strings.HasPrefix(line, metaEnvFile+"=") {
+			config.EnvFiles = append(config.EnvFiles, strings.TrimPrefix(line, metaEnvFile+"="))
+		} else if strings.HasPrefix(line, metaEnvSource+"=") {
+			parts := strings.SplitN(strings.TrimPrefix(line, metaEnvSource+"="), " ", 2)
+			if len(parts) == 2 {
+				for _, key := range strings.Split(parts[0], ",") {
+					config.EnvSources[key] = parts[1]
 				}
 			}
 		} else if strings.HasPrefix(line, "StandardOutput=") {
-			outLine := strings.TrimPrefix(line, "StandardOutput=")
-			if strings.HasPrefix(outLine, "append:") {
-				config.OutLogPath = strings.TrimPrefix(outLine, "append:")
+			value := strings.TrimPrefix(line, "StandardOutput=")
+			if value == "journal" {
+				config.LogBackend = LogBackendJournal
 			}
+			config.OutLogPath = parseLogOutput(value)
 		} else if strings.HasPrefix(line, "StandardError=") {
-			errLine := strings.TrimPrefix(line, "StandardError=")
-			if strings.HasPrefix(errLine, "append:") {
-				config.ErrLogPath = strings.TrimPrefix(errLine, "append:")
-			}
+			config.ErrLogPath = parseLogOutput(strings.TrimPrefix(line, "StandardError="))
 		}
 	}
 	
+	// Units written before the command was recorded are split on
+	// ExecStart=, taking its first word as the interpreter
+	if !recorded && len(execArgv) > 0 {
+		config.Interpreter = execArgv[0]
+		if len(execArgv) > 1 {
+			config.Script = execArgv[1]
+			config.Args = execArgv[2:]
+		}
+	}
+	if n := len(execArgv) - len(config.Args) - 1; n > 0 {
+		config.ExecInterp = JoinArgs(execArgv[:n])
+	}
+	
+	// Both streams going to the same file means a combined log
+	if config.OutLogPath != "" && config.OutLogPath == config.ErrLogPath && config.OutLogPath != NullLogPath {
+		config.LogPath = config.OutLogPath
+	}
+	
 	// Generate default PID path
 	config.PidPath = fmt.Sprintf("/tmp/%s.pid", serviceName)
 	
 	return config
 }
 
-// Flush removes logs for all apps or a specific app
-func (m *Manager) Flush(appName string) error {
-	if appName == "" {
-		// Flush all logs
-		cmd := []string{"journalctl"}
-		if m.userMode {
-			cmd = append(cmd, "--user")
-		}
-		cmd = append(cmd, "--rotate")
-		return exec.Command(cmd[0], cmd[1:]...).Run()
-	}
-
-	// Flush specific app logs
-	serviceName := m.serviceName(appName)
-	cmd := []string{"journalctl"}
-	if m.userMode {
-		cmd = append(cmd, "--user")
-	}
-	cmd = append(cmd, "--unit", serviceName, "--rotate")
-	return exec.Command(cmd[0], cmd[1:]...).Run()
+// parseLogOutput extracts the log file path from a StandardOutput=/StandardError= value
+func parseLogOutput(value string) string {
+	switch {
+	case value == "null":
+		return NullLogPath
+	case strings.HasPrefix(value, "append:"):
+		return unescapeSpecifiers(strings.TrimPrefix(value, "append:"))
+	case strings.HasPrefix(value, "file:"):
+		return unescapeSpecifiers(strings.TrimPrefix(value, "file:"))
+	case strings.HasPrefix(value, "truncate:"):
+		return unescapeSpecifiers(strings.TrimPrefix(value, "truncate:"))
+	}
+	return ""
 }
 
-// generateServiceFile creates the systemd service file content
-func (m *Manager) generateServiceFile(config AppConfig) string {
-	workingDir := config.Cwd
-	if workingDir == "" {
-		workingDir, _ = os.Getwd()
+// Flush removes logs for all apps or a specific app. File logs are
+// truncated; journald logs can't be deleted per unit, so a cursor is recorded
+// and older journal entries are no longer shown by Logs.
+func (m *Manager) Flush(appName string) error {
+	processes, err := m.List()
+	if err != nil {
+		return fmt.Errorf("failed to get process list: %v", err)
 	}
-
-	// Create PM2-style log directory
-	home, _ := os.UserHomeDir()
-	logDir := filepath.Join(home, ".pm2", "logs")
-	os.MkdirAll(logDir, 0755)
-	
-	outLog := filepath.Join(logDir, config.Name+"-out.log")
-	errLog := filepath.Join(logDir, config.Name+"-error.log")
-
-	var execStart string
 	
-	if config.Interpreter != "" {
-		// Use explicit interpreter: "python3 script.py args"
-		interpreterFields := strings.Fields(config.Interpreter)
-		fullPath, err := exec.LookPath(interpreterFields[0])
-		
-		var interpreterPath string
-		if err != nil {
-			// Fallback to original interpreter if not found in PATH
-			interpreterPath = interpreterFields[0]
-		} else {
-			interpreterPath = fullPath
+	found := false
+	for _, process := range processes {
+		if appName != "" && process.Name != appName {
+			continue
 		}
+		found = true
 		
-		// Add interpreter arguments if any
-		if len(interpreterFields) > 1 {
-			interpreterPath += " " + strings.Join(interpreterFields[1:], " ")
+		if process.PM2Env.LogBackend == LogBackendJournal {
+			serviceName := m.serviceNameWithID(process.PM2Env.ID, process.Name)
+			if err := m.saveJournalCursor(serviceName); err != nil {
+				return err
+			}
+			continue
 		}
 		
-		execStart = interpreterPath + " " + config.Script
-		if config.Args != "" {
-			execStart += " " + config.Args
+		paths := []string{process.PM2Env.PMOutLogPath}
+		if process.PM2Env.PMLogPath == "" {
+			paths = append(paths, process.PM2Env.PMErrLogPath)
 		}
-	} else {
-		// Auto-detect interpreter or use script directly
-		if strings.HasSuffix(config.Script, ".py") {
-			pythonPath, err := exec.LookPath("python3")
-			if err != nil {
-				pythonPath = "python3"
+		for _, path := range paths {
+			if path == "" || path == NullLogPath {
+				continue
 			}
-			execStart = pythonPath + " " + config.Script
-		} else if strings.HasSuffix(config.Script, ".js") {
-			nodePath, err := exec.LookPath("node")
-			if err != nil {
-				nodePath, err = exec.LookPath("nodejs")
-				if err != nil {
-					nodePath = "node"
-				}
+			if err := m.truncateFile(path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to flush %s: %v", path, err)
 			}
-			execStart = nodePath + " " + config.Script
-		} else {
-			// Use script directly (should have shebang)
-			execStart = config.Script
 		}
-		
-		if config.Args != "" {
-			execStart += " " + config.Args
+	}
+	
+	if appName != "" && !found {
+		return fmt.Errorf("process '%s' not found", appName)
+	}
+	return nil
+}
+
+// journalCursorPath returns where the flush cursor of a journal-backed unit is kept
+func (m *Manager) journalCursorPath(serviceName string) string {
+	return filepath.Join(m.stateDir(), "journal", serviceName+".cursor")
+}
+
+// saveJournalCursor records the newest journal entry of a unit as flushed
+func (m *Manager) saveJournalCursor(serviceName string) error {
+	cursor, err := logs.LastJournalCursor(m.journalSource(serviceName))
+	if err != nil {
+		return err
+	}
+	if cursor == "" {
+		return nil
+	}
+	
+	path := m.journalCursorPath(serviceName)
+	if err := m.mkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %v", filepath.Dir(path), err)
+	}
+	return m.writeFile(path, []byte(cursor), 0644)
+}
+
+// journalSource returns the journal a unit logs to
+func (m *Manager) journalSource(serviceName string) logs.JournalSource {
+	src := logs.JournalSource{Unit: serviceName, UserMode: m.userMode}
+	if m.asUser != nil {
+		src.UID = m.asUser.Uid
+	}
+	return src
+}
+
+// loadJournalCursor returns the flush cursor of a journal-backed unit, if any
+func (m *Manager) loadJournalCursor(serviceName string) string {
+	data, err := os.ReadFile(m.journalCursorPath(serviceName))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// generateServiceFile renders the unit template of an app and adds the
+// directives pm2go manages itself to the [Service] section
+func (m *Manager) generateServiceFile(config AppConfig) (string, error) {
+	serviceName := m.serviceNameWithID(config.ID, config.Name)
+	return m.generateUnit(config, serviceName, m.envFilePath(serviceName), true)
+}
+
+// ExportUnit renders a standalone unit for an app: named after the app,
+// reading its environment from envFile and without pm2go's metadata
+func (m *Manager) ExportUnit(config AppConfig, envFile string) (string, error) {
+	return m.generateUnit(config, config.Name, envFile, false)
+}
+
+// generateUnit renders the unit of an app as serviceName. With meta, the
+// settings pm2go needs to read the app back are recorded in the unit.
+func (m *Manager) generateUnit(config AppConfig, serviceName, envFile string, meta bool) (string, error) {
+	config = substitutePMID(config)
+	
+	workingDir := config.Cwd
+	outLog, errLog := m.resolveLogPaths(config, workingDir)
+	stdOutput, stdError := logOutput(outLog), logOutput(errLog)
+	syslogIdentifier := ""
+	if m.logBackend(config) == LogBackendJournal {
+		// Leave output to journald, tagged with the app name
+		stdOutput, stdError = "journal", "journal"
+		syslogIdentifier = config.Name
+	}
+
+	argv := ExecArgv(config)
+	if len(argv) > len(config.Args)+1 {
+		// The interpreter runs from its full path
+		argv[0] = resolveInterpreter(argv[0])
+	}
+	execStart := FormatExecLine(argv)
+
+	service, err := m.renderUnit(m.unitTemplatePath(config), UnitData{
+		AppConfig:        config,
+		ServiceName:      serviceName,
+		UserMode:         m.userMode,
+		ServiceUser:      m.serviceUser(config),
+		WorkingDirectory: escapeSpecifiers(workingDir),
+		ExecStart:        execStart,
+		StandardOutput:   stdOutput,
+		StandardError:    stdError,
+		SyslogIdentifier: syslogIdentifier,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Hardening directives belong to [Service]
+	if directives := sandboxDirectives(config, workingDir, outLog, errLog); len(directives) > 0 {
+		service = appendToSection(service, "Service", sandboxLines(directives))
+	}
+	service = addSystemdSections(service, config.Systemd)
+
+	// Record pm2go metadata; systemd ignores keys starting with X-
+	if meta {
+		service = appendMetadata(service, config)
+	}
+
+	// Environment values live in a file only the owner can read
+	if len(config.Env) > 0 {
+		service = appendToSection(service, "Service", "\nEnvironmentFile="+envFile+"\n")
+	}
+
+	return service, nil
+}
+
+// appendMetadata records the settings of an app that systemd doesn't know
+// about in its unit
+func appendMetadata(service string, config AppConfig) string {
+	metaLines := ""
+	if config.EnvProfile != "" {
+		metaLines += fmt.Sprintf("%s=%s\n", metaEnvProfile, config.EnvProfile)
+	}
+	if config.Ecosystem != "" {
+		metaLines += fmt.Sprintf("%s=%s\n", metaEcosystem, config.Ecosystem)
+	}
+	if config.InheritEnv != "" {
+		metaLines += fmt.Sprintf("%s=%s\n", metaInheritEnv, config.InheritEnv)
+	}
+	if config.Sandbox != "" {
+		metaLines += fmt.Sprintf("%s=%s\n", metaSandbox, config.Sandbox)
+	}
+	overrides := make([]string, 0, len(config.SandboxSet))
+	for directive := range config.SandboxSet {
+		overrides = append(overrides, directive)
+	}
+	sort.Strings(overrides)
+	for _, directive := range overrides {
+		metaLines += fmt.Sprintf("%s=%s=%s\n", metaSandboxSet, directive, config.SandboxSet[directive])
+	}
+	if config.Template != "" {
+		metaLines += fmt.Sprintf("%s=%s\n", metaTemplate, config.Template)
+	}
+	// ExecStart= has the interpreter resolved and split, so the command is
+	// kept as given
+	if config.Script != "" {
+		metaLines += fmt.Sprintf("%s=%s\n", metaScript, QuoteExecArg(config.Script))
+	}
+	if config.Interpreter != "" {
+		metaLines += fmt.Sprintf("%s=%s\n", metaInterp, QuoteExecArg(config.Interpreter))
+	}
+	if len(config.Args) > 0 {
+		metaLines += fmt.Sprintf("%s=%s\n", metaArgs, FormatExecLine(config.Args))
+	}
+	// User= is written for the default user too
+	if config.User != "" {
+		metaLines += fmt.Sprintf("%s=%s\n", metaUser, config.User)
+	}
+	if config.Group != "" {
+		metaLines += fmt.Sprintf("%s=%s\n", metaGroup, config.Group)
+	}
+	// A log_file next to split files isn't written, but kept for PM2
+	if config.LogFile != "" && (config.OutFile != "" || config.ErrorFile != "") {
+		metaLines += fmt.Sprintf("%s=%s\n", metaLogFile, config.LogFile)
+	}
+	for _, path := range config.EnvFiles {
+		metaLines += fmt.Sprintf("%s=%s\n", metaEnvFile, path)
+	}
+	metaLines += envSourceLines(config)
+	if metaLines != "" {
+		service = appendToSection(service, "Service", "\n"+metaLines)
+	}
+	return service
+}
+
+// envValueUnescaper unescapes the value of a quoted Environment= line
+var envValueUnescaper = strings.NewReplacer(`\\`, `\`, `\"`, `"`, `\n`, "\n")
+
+// envSourceLines records the source of env variables that didn't come from
+// the app's default source (the shell, or the ecosystem file's env block)
+func envSourceLines(config AppConfig) string {
+	defaultSource := EnvSourceShell
+	if config.Ecosystem != "" {
+		defaultSource = EnvSourceEcosystem
+	}
+	
+	keysBySource := make(map[string][]string)
+	for key := range config.Env {
+		if source := config.EnvSource(key); source != defaultSource {
+			keysBySource[source] = append(keysBySource[source], key)
 		}
 	}
+	
+	sources := make([]string, 0, len(keysBySource))
+	for source := range keysBySource {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	
+	lines := ""
+	for _, source := range sources {
+		keys := keysBySource[source]
+		sort.Strings(keys)
+		lines += fmt.Sprintf("%s=%s %s\n", metaEnvSource, strings.Join(keys, ","), source)
+	}
+	return lines
+}
 
-	var service string
-	if m.userMode {
-		// In user mode, don't specify User= as it causes "operation not permitted"
-		service = fmt.Sprintf(`[Unit]
-Description=PM2 App: %s
-After=network.target
-
-[Service]
-Type=simple
-WorkingDirectory=%s
-ExecStart=%s
-Restart=always
-RestartSec=3
-StandardOutput=append:%s
-StandardError=append:%s
-
-[Install]
-WantedBy=default.target
-`, config.Name, workingDir, execStart, outLog, errLog)
-	} else {
-		// In system mode, specify the user
-		service = fmt.Sprintf(`[Unit]
-Description=PM2 App: %s
-After=network.target
-
-[Service]
-Type=simple
-User=%s
-WorkingDirectory=%s
-ExecStart=%s
-Restart=always
-RestartSec=3
-StandardOutput=append:%s
-StandardError=append:%s
-
-[Install]
-WantedBy=default.target
-`, config.Name, m.getCurrentUser(), workingDir, execStart, outLog, errLog)
-	}
-
-	// Add environment variables if present
+// substitutePMID replaces ${pm_id} left by ecosystem interpolation with the
+// app's ID
+func substitutePMID(config AppConfig) AppConfig {
+	id := strconv.Itoa(config.ID)
+	replace := func(value string) string {
+		return strings.ReplaceAll(value, PMIDPlaceholder, id)
+	}
+	
+	config.Script = replace(config.Script)
+	config.Interpreter = replace(config.Interpreter)
+	config.Cwd = replace(config.Cwd)
+	if config.Args != nil {
+		args := make([]string, len(config.Args))
+		for i, arg := range config.Args {
+			args[i] = replace(arg)
+		}
+		config.Args = args
+	}
+	config.OutFile = replace(config.OutFile)
+	config.ErrorFile = replace(config.ErrorFile)
+	config.LogFile = replace(config.LogFile)
+	
 	if config.Env != nil {
-		envLines := ""
+		env := make(map[string]string, len(config.Env))
 		for key, value := range config.Env {
-			// Quote the value to handle spaces and special characters
-			quotedValue := fmt.Sprintf("\"%s\"", strings.ReplaceAll(value, "\"", "\\\""))
-			envLines += fmt.Sprintf("Environment=%s=%s\n", key, quotedValue)
+			env[key] = replace(value)
 		}
-		service = strings.Replace(service, "[Install]", envLines+"\n[Install]", 1)
+		config.Env = env
 	}
+	return config
+}
 
-	return service
+// logBackend returns the effective log backend of an app
+func (m *Manager) logBackend(config AppConfig) string {
+	if config.LogBackend != "" {
+		return config.LogBackend
+	}
+	if m.defaultLogBackend != "" {
+		return m.defaultLogBackend
+	}
+	return LogBackendFile
+}
+
+// resolveLogPaths returns the stdout and stderr log files for an app.
+// Custom paths are resolved against the working directory, and a combined
+// log_file receives both streams unless out_file/error_file are given:
+// systemd can't write a stream to two files.
+func (m *Manager) resolveLogPaths(config AppConfig, workingDir string) (string, string) {
+	logDir := m.LogDir()
+	
+	outLog := filepath.Join(logDir, config.Name+"-out.log")
+	errLog := filepath.Join(logDir, config.Name+"-error.log")
+	
+	if config.LogFile != "" && config.OutFile == "" && config.ErrorFile == "" {
+		outLog = m.resolveLogPath(config.LogFile, workingDir)
+		errLog = outLog
+	}
+	if config.OutFile != "" {
+		outLog = m.resolveLogPath(config.OutFile, workingDir)
+	}
+	if config.ErrorFile != "" {
+		errLog = m.resolveLogPath(config.ErrorFile, workingDir)
+	}
+	
+	return outLog, errLog
+}
+
+// resolveLogPath makes a log path absolute
+func (m *Manager) resolveLogPath(path, workingDir string) string {
+	if path == NullLogPath {
+		return path
+	}
+	
+	if strings.HasPrefix(path, "~/") {
+		path = filepath.Join(m.homeDir(), path[2:])
+	} else if !filepath.IsAbs(path) {
+		path = filepath.Join(workingDir, path)
+	}
+	return path
+}
+
+// createLogDirs creates the PM2-style log directory and the directories of
+// an app's log files
+func (m *Manager) createLogDirs(config AppConfig) error {
+	config = substitutePMID(config)
+	outLog, errLog := m.resolveLogPaths(config, config.Cwd)
+	
+	dirs := []string{m.LogDir()}
+	for _, path := range []string{outLog, errLog} {
+		if path != NullLogPath {
+			dirs = append(dirs, filepath.Dir(path))
+		}
+	}
+	for _, dir := range dirs {
+		if err := m.mkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create log directory: %v", err)
+		}
+	}
+	return nil
+}
+
+// logOutput returns the StandardOutput=/StandardError= value for a log path
+func logOutput(path string) string {
+	if path == NullLogPath {
+		return "null"
+	}
+	return "append:" + escapeSpecifiers(path)
 }
 
 // getServiceDir returns the directory where service files should be stored
 func (m *Manager) getServiceDir() string {
+	dir := m.serviceDir()
+	if m.userMode {
+		m.mkdirAll(dir, 0755)
+	}
+	return dir
+}
+
+// serviceDir returns the directory of the service files without creating it
+func (m *Manager) serviceDir() string {
 	if m.userMode {
-		home, _ := os.UserHomeDir()
-		dir := filepath.Join(home, ".config/systemd/user")
-		os.MkdirAll(dir, 0755)
-		return dir
+		return filepath.Join(m.homeDir(), ".config/systemd/user")
 	}
 	return "/etc/systemd/system"
 }
 
 // getCurrentUser returns the current username
 func (m *Manager) getCurrentUser() string {
+	if m.asUser != nil {
+		return m.asUser.Username
+	}
 	if user := os.Getenv("USER"); user != "" {
 		return user
 	}
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
 	return "nobody"
 }
 
 // systemdReload reloads the systemd daemon
 func (m *Manager) systemdReload() error {
-	cmd := []string{"systemctl"}
-	if m.userMode {
-		cmd = append(cmd, "--user")
-	}
-	cmd = append(cmd, "daemon-reload")
-
-	return exec.Command(cmd[0], cmd[1:]...).Run()
+	return m.runSystemctl("daemon-reload")
 }
 
 // systemdCommand executes a systemctl command
 func (m *Manager) systemdCommand(action, serviceName string) error {
-	cmd := []string{"systemctl"}
-	if m.userMode {
-		cmd = append(cmd, "--user")
-	}
-	cmd = append(cmd, action, serviceName)
-
-	return exec.Command(cmd[0], cmd[1:]...).Run()
+	return m.runSystemctl(action, serviceName)
 }
 
 // getServicePID returns the PID of a service
 func (m *Manager) getServicePID(serviceName string) int {
-	cmd := []string{"systemctl"}
-	if m.userMode {
-		cmd = append(cmd, "--user")
-	}
-	cmd = append(cmd, "show", serviceName, "--property=MainPID", "--value")
-
-	output, err := exec.Command(cmd[0], cmd[1:]...).Output()
+	output, err := m.systemctl("show", serviceName, "--property=MainPID", "--value").Output()
 	if err != nil {
 		return 0
 	}
@@ -567,13 +1013,7 @@ func (m *Manager) getServicePID(serviceName string) int {
 
 // getServiceStatus returns the status of a service
 func (m *Manager) getServiceStatus(serviceName string) string {
-	cmd := []string{"systemctl"}
-	if m.userMode {
-		cmd = append(cmd, "--user")
-	}
-	cmd = append(cmd, "is-active", serviceName)
-
-	output, err := exec.Command(cmd[0], cmd[1:]...).Output()
+	output, err := m.systemctl("is-active", serviceName).Output()
 	if err != nil {
 		// Check if service is loaded but not active
 		return "stopped"
@@ -676,13 +1116,7 @@ func (m *Manager) getServiceCPU(pid int) int {
 
 // getServiceUptime returns uptime in milliseconds for a service
 func (m *Manager) getServiceUptime(serviceName string) int64 {
-	cmd := []string{"systemctl"}
-	if m.userMode {
-		cmd = append(cmd, "--user")
-	}
-	cmd = append(cmd, "show", serviceName, "--property=ActiveEnterTimestamp", "--value")
-	
-	output, err := exec.Command(cmd[0], cmd[1:]...).Output()
+	output, err := m.systemctl("show", serviceName, "--property=ActiveEnterTimestamp", "--value").Output()
 	if err != nil {
 		return 0
 	}
@@ -717,111 +1151,186 @@ func (m *Manager) mapSystemdStatus(status string) string {
 }
 
 // Logs shows logs for a specific app or all apps
-func (m *Manager) Logs(appName string, lines int, follow bool) error {
-	if appName != "" {
-		// Show logs for specific app from PM2-style log files
-		return m.showAppLogs(appName, lines, follow)
-	} else {
-		// Show logs for all PM2go apps
-		return m.showAllAppLogs(lines, follow)
+func (m *Manager) Logs(appName string, opts LogOptions) error {
+	sources, journalSources, found, err := m.collectLogSources(appName, opts.Filter)
+	if err != nil {
+		return err
+	}
+	if !found {
+		fmt.Println("No processes found")
+		return nil
 	}
-}
 
-func (m *Manager) showAppLogs(appName string, lines int, follow bool) error {
-	// Find the process to get log file paths
-	processes, err := m.List()
-	if err != nil {
-		return fmt.Errorf("failed to get process list: %v", err)
+	var mu sync.Mutex
+	print := func(entry logs.Entry) {
+		mu.Lock()
+		defer mu.Unlock()
+		if opts.JSON {
+			fmt.Println(logs.FormatJSON(entry))
+		} else {
+			fmt.Println(logs.Format(entry, opts.Raw))
+		}
 	}
-	
-	var targetProcess *ProcessInfo
-	for _, process := range processes {
-		if process.Name == appName {
-			targetProcess = &process
-			break
+
+	// Show recent lines from every source
+	for _, src := range sources {
+		entries, err := logs.Tail(src, opts.Lines, opts.Filter)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", src.Path, err)
+		}
+
+		if !opts.Raw && !opts.JSON {
+			fmt.Printf("%s last %d lines:\n", src.Path, len(entries))
+		}
+		for _, entry := range entries {
+			print(entry)
 		}
 	}
-	
-	if targetProcess == nil {
-		return fmt.Errorf("process '%s' not found", appName)
+
+	for _, src := range journalSources {
+		entries, err := logs.JournalTail(src, opts.Lines, opts.Filter)
+		if err != nil {
+			return fmt.Errorf("failed to read journal of %s: %v", src.App, err)
+		}
+
+		if !opts.Raw && !opts.JSON {
+			fmt.Printf("journal (%s) last %d lines:\n", src.Unit, len(entries))
+		}
+		for _, entry := range entries {
+			print(entry)
+		}
 	}
-	
-	// Get log file paths
-	outLogPath := targetProcess.PM2Env.PMOutLogPath
-	errLogPath := targetProcess.PM2Env.PMErrLogPath
-	
-	if outLogPath == "" || errLogPath == "" {
-		return fmt.Errorf("log paths not configured for process '%s'", appName)
+
+	if !opts.Follow {
+		return nil
 	}
-	
-	// Use tail to show logs
-	cmd := []string{"tail"}
-	
-	if lines > 0 {
-		cmd = append(cmd, "-n", fmt.Sprintf("%d", lines))
+
+	return followSources(sources, journalSources, opts.Filter, nil, print)
+}
+
+// FollowLogs streams new log entries of a specific app or all apps to emit
+// until stop is closed. emit may be called from several goroutines.
+func (m *Manager) FollowLogs(appName string, filter logs.Filter, stop <-chan struct{}, emit func(logs.Entry)) error {
+	sources, journalSources, found, err := m.collectLogSources(appName, filter)
+	if err != nil {
+		return err
 	}
-	
-	if follow {
-		cmd = append(cmd, "-f")
+	if !found {
+		return fmt.Errorf("no processes found")
 	}
-	
-	// Show both stdout and stderr
-	cmd = append(cmd, outLogPath, errLogPath)
-	
-	// Execute tail command
-	execCmd := exec.Command(cmd[0], cmd[1:]...)
-	execCmd.Stdout = os.Stdout
-	execCmd.Stderr = os.Stderr
-	execCmd.Stdin = os.Stdin
-	
-	return execCmd.Run()
+	return followSources(sources, journalSources, filter, stop, emit)
+}
+
+// followSources follows file and journal sources together
+func followSources(sources []logs.Source, journalSources []logs.JournalSource, filter logs.Filter, stop <-chan struct{}, emit func(logs.Entry)) error {
+	if len(journalSources) > 0 {
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- logs.FollowJournal(journalSources, filter, stop, emit)
+		}()
+		if len(sources) == 0 {
+			return <-errCh
+		}
+	}
+
+	return logs.Follow(sources, filter, stop, emit)
 }
 
-func (m *Manager) showAllAppLogs(lines int, follow bool) error {
+// collectLogSources returns the log files and journal units of a specific app
+// or all apps, and whether there are any processes at all
+func (m *Manager) collectLogSources(appName string, filter logs.Filter) ([]logs.Source, []logs.JournalSource, bool, error) {
 	// Get all processes
 	processes, err := m.List()
 	if err != nil {
-		return fmt.Errorf("failed to get process list: %v", err)
+		return nil, nil, false, fmt.Errorf("failed to get process list: %v", err)
 	}
-	
-	if len(processes) == 0 {
-		fmt.Println("No processes found")
-		return nil
-	}
-	
-	// Collect all log file paths
-	var logFiles []string
-	for _, process := range processes {
-		if process.PM2Env.PMOutLogPath != "" {
-			logFiles = append(logFiles, process.PM2Env.PMOutLogPath)
+
+	if appName != "" {
+		var targetProcess *ProcessInfo
+		for i, process := range processes {
+			if process.Name == appName {
+				targetProcess = &processes[i]
+				break
+			}
+		}
+
+		if targetProcess == nil {
+			return nil, nil, false, fmt.Errorf("process '%s' not found", appName)
 		}
-		if process.PM2Env.PMErrLogPath != "" {
-			logFiles = append(logFiles, process.PM2Env.PMErrLogPath)
+		if !filter.WantsStream(logs.StreamCombined) {
+			if targetProcess.PM2Env.LogBackend == LogBackendJournal {
+				return nil, nil, false, fmt.Errorf("process '%s' logs to the journal, which doesn't tell stdout from stderr, so --out and --err can't be used", appName)
+			}
+			if path := targetProcess.PM2Env.PMLogPath; path != "" {
+				return nil, nil, false, fmt.Errorf("process '%s' writes both streams to %s, --out and --err can't tell them apart", appName, path)
+			}
 		}
+		processes = []ProcessInfo{*targetProcess}
+	} else if len(processes) == 0 {
+		return nil, nil, false, nil
 	}
-	
-	if len(logFiles) == 0 {
-		return fmt.Errorf("no log files found")
+
+	// Collect all log sources for the selected streams
+	var sources []logs.Source
+	var journalSources []logs.JournalSource
+	for _, process := range processes {
+		if process.PM2Env.LogBackend == LogBackendJournal {
+			if !filter.WantsStream(logs.StreamCombined) {
+				continue
+			}
+			serviceName := m.serviceNameWithID(process.PM2Env.ID, process.Name)
+			src := m.journalSource(serviceName)
+			src.App = process.Name
+			src.ID = process.PM2Env.ID
+			src.AfterCursor = m.loadJournalCursor(serviceName)
+			journalSources = append(journalSources, src)
+			continue
+		}
+		sources = append(sources, m.logSources(process, filter)...)
 	}
-	
-	// Use tail to show logs from all files
-	cmd := []string{"tail"}
-	
-	if lines > 0 {
-		cmd = append(cmd, "-n", fmt.Sprintf("%d", lines))
+
+	if len(sources) == 0 && len(journalSources) == 0 {
+		if appName != "" {
+			return nil, nil, false, fmt.Errorf("log paths not configured for process '%s'", appName)
+		}
+		return nil, nil, false, fmt.Errorf("no log files found")
 	}
+
+	return sources, journalSources, true, nil
+}
+
+// logSources returns the log files of a process for the streams the filter wants
+func (m *Manager) logSources(process ProcessInfo, filter logs.Filter) []logs.Source {
+	var sources []logs.Source
 	
-	if follow {
-		cmd = append(cmd, "-f")
+	// A combined log holds both streams in one file
+	if path := process.PM2Env.PMLogPath; path != "" {
+		if !filter.WantsStream(logs.StreamCombined) {
+			return nil
+		}
+		return []logs.Source{{
+			App:    process.Name,
+			ID:     process.PM2Env.ID,
+			Stream: logs.StreamCombined,
+			Path:   path,
+		}}
 	}
 	
-	cmd = append(cmd, logFiles...)
-	
-	// Execute tail command
-	execCmd := exec.Command(cmd[0], cmd[1:]...)
-	execCmd.Stdout = os.Stdout
-	execCmd.Stderr = os.Stderr
-	execCmd.Stdin = os.Stdin
-	
-	return execCmd.Run()
-}
\ No newline at end of file
+	if path := process.PM2Env.PMOutLogPath; path != "" && path != NullLogPath && filter.WantsStream(logs.StreamOut) {
+		sources = append(sources, logs.Source{
+			App:    process.Name,
+			ID:     process.PM2Env.ID,
+			Stream: logs.StreamOut,
+			Path:   path,
+		})
+	}
+	if path := process.PM2Env.PMErrLogPath; path != "" && path != NullLogPath && filter.WantsStream(logs.StreamErr) {
+		sources = append(sources, logs.Source{
+			App:    process.Name,
+			ID:     process.PM2Env.ID,
+			Stream: logs.StreamErr,
+			Path:   path,
+		})
+	}
+	return sources
+}
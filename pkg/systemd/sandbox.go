@@ -0,0 +1,159 @@
+package systemd
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Sandbox presets an app can run with
+const (
+	SandboxNone   = "none"   // no hardening (default)
+	SandboxBasic  = "basic"  // protections that rarely break apps
+	SandboxStrict = "strict" // read-only system and home, only the app's directories are writable
+)
+
+// basicSandbox holds the directives of the basic preset
+var basicSandbox = map[string]string{
+	"NoNewPrivileges":       "yes",
+	"PrivateTmp":            "yes",
+	"ProtectSystem":         "full",
+	"ProtectKernelTunables": "yes",
+	"ProtectKernelModules":  "yes",
+	"ProtectControlGroups":  "yes",
+}
+
+// strictSandbox holds the directives the strict preset adds to basic
+var strictSandbox = map[string]string{
+	"ProtectSystem":           "strict",
+	"ProtectHome":             "read-only",
+	"PrivateDevices":          "yes",
+	"ProtectKernelLogs":       "yes",
+	"ProtectClock":            "yes",
+	"ProtectHostname":         "yes",
+	"RestrictAddressFamilies": "AF_UNIX AF_INET AF_INET6",
+	"RestrictNamespaces":      "yes",
+	"RestrictRealtime":        "yes",
+	"RestrictSUIDSGID":        "yes",
+	"LockPersonality":         "yes",
+	"SystemCallArchitectures": "native",
+}
+
+// directivePattern matches systemd directive names
+var directivePattern = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+
+// ValidateSandbox checks a sandbox preset and its directive overrides
+func ValidateSandbox(preset string, overrides map[string]string) error {
+	switch preset {
+	case "", SandboxNone, SandboxBasic, SandboxStrict:
+	default:
+		return fmt.Errorf("unknown sandbox '%s' (expected %s, %s or %s)", preset, SandboxNone, SandboxBasic, SandboxStrict)
+	}
+	for directive, value := range overrides {
+		if !directivePattern.MatchString(directive) {
+			return fmt.Errorf("invalid sandbox directive '%s'", directive)
+		}
+		if strings.Contains(value, "\n") {
+			return fmt.Errorf("sandbox directive %s can't contain newlines", directive)
+		}
+	}
+	return nil
+}
+
+// SandboxDirectives returns the [Service] directives of an app's sandbox
+func (m *Manager) SandboxDirectives(config AppConfig) map[string]string {
+	config = substitutePMID(config)
+	outLog, errLog := m.resolveLogPaths(config, config.Cwd)
+	if m.logBackend(config) == LogBackendJournal {
+		outLog, errLog = NullLogPath, NullLogPath
+	}
+	return sandboxDirectives(config, config.Cwd, outLog, errLog)
+}
+
+// sandboxDirectives applies the preset and overrides of an app. The strict
+// preset keeps the working directory and log directories writable; the
+// paths are prefixed with '-' so missing ones are ignored.
+func sandboxDirectives(config AppConfig, workingDir string, logPaths ...string) map[string]string {
+	directives := make(map[string]string)
+	switch config.Sandbox {
+	case SandboxBasic, SandboxStrict:
+		for directive, value := range basicSandbox {
+			directives[directive] = value
+		}
+	}
+
+	if config.Sandbox == SandboxStrict {
+		for directive, value := range strictSandbox {
+			directives[directive] = value
+		}
+
+		writable := []string{quotePathWord("-" + workingDir)}
+		for _, path := range logPaths {
+			if path == NullLogPath {
+				continue
+			}
+			if dir := quotePathWord("-" + filepath.Dir(path)); !containsString(writable, dir) {
+				writable = append(writable, dir)
+			}
+		}
+		directives["ReadWritePaths"] = strings.Join(writable, " ")
+	}
+
+	// An empty override removes a directive set by the preset
+	for directive, value := range config.SandboxSet {
+		if value == "" {
+			delete(directives, directive)
+		} else {
+			directives[directive] = value
+		}
+	}
+	return directives
+}
+
+// sandboxLines renders sandbox directives as sorted unit file lines
+func sandboxLines(directives map[string]string) string {
+	names := make([]string, 0, len(directives))
+	for name := range directives {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := ""
+	for _, name := range names {
+		lines += fmt.Sprintf("%s=%s\n", name, directives[name])
+	}
+	return lines
+}
+
+// containsString reports whether a slice contains a string
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// AnalyzeSecurity returns the "systemd-analyze security" report of an app
+func (m *Manager) AnalyzeSecurity(identifier string) (string, error) {
+	serviceName, err := m.findServiceByIdentifier(identifier)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := exec.LookPath("systemd-analyze"); err != nil {
+		return "", fmt.Errorf("systemd-analyze not found")
+	}
+
+	args := append(m.scopeArgs(), "security", "--no-pager", serviceName+".service")
+
+	output, err := exec.Command("systemd-analyze", args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("systemd-analyze security failed: %s", strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
+}
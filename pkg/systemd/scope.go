@@ -0,0 +1,169 @@
+package systemd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/wojtekw92/pm2go/pkg/config"
+)
+
+// Scopes of the systemd instance pm2go manages
+const (
+	ScopeUser   = "user"   // the user's own service manager (default when not root)
+	ScopeSystem = "system" // the system service manager (default for root)
+)
+
+// SetScope selects the systemd instance to manage. An empty scope keeps the
+// default. asUser names another user whose service manager root operates on
+// instead of its own; it implies the user scope.
+func (m *Manager) SetScope(scope, asUser string) error {
+	switch scope {
+	case "":
+	case ScopeUser:
+		m.userMode = true
+	case ScopeSystem:
+		if asUser != "" {
+			return fmt.Errorf("another user's apps always live in the user scope")
+		}
+		m.userMode = false
+	default:
+		return fmt.Errorf("unknown scope '%s' (expected %s or %s)", scope, ScopeUser, ScopeSystem)
+	}
+
+	if asUser == "" {
+		return nil
+	}
+	if os.Getuid() != 0 {
+		return fmt.Errorf("managing another user's apps needs root")
+	}
+
+	u, err := user.Lookup(asUser)
+	if err != nil {
+		if u, err = user.LookupId(asUser); err != nil {
+			return fmt.Errorf("user '%s' does not exist", asUser)
+		}
+	}
+	m.userMode = true
+	m.asUser = u
+	return nil
+}
+
+// Scope describes the managed systemd instance: "system", "user", or
+// "user:<name>" for another user's service manager
+func (m *Manager) Scope() string {
+	if !m.userMode {
+		return ScopeSystem
+	}
+	if m.asUser != nil {
+		return ScopeUser + ":" + m.asUser.Username
+	}
+	return ScopeUser
+}
+
+// AsUser returns the user whose apps root manages instead of its own, or ""
+func (m *Manager) AsUser() string {
+	if m.asUser != nil {
+		return m.asUser.Username
+	}
+	return ""
+}
+
+// scopeArgs returns the systemctl arguments that select the managed instance.
+// Another user's manager is reached over its runtime directory bus through
+// the local machine (the same transport machinectl shell uses).
+func (m *Manager) scopeArgs() []string {
+	if !m.userMode {
+		return nil
+	}
+	if m.asUser != nil {
+		return []string{"--user", "--machine=" + m.asUser.Username + "@.host"}
+	}
+	return []string{"--user"}
+}
+
+// systemctl builds a systemctl command for the managed instance
+func (m *Manager) systemctl(args ...string) *exec.Cmd {
+	return exec.Command("systemctl", append(m.scopeArgs(), args...)...)
+}
+
+// uid returns the ID of the user whose apps are managed
+func (m *Manager) uid() int {
+	if m.asUser != nil {
+		uid, _ := strconv.Atoi(m.asUser.Uid)
+		return uid
+	}
+	return os.Getuid()
+}
+
+// homeDir returns the home directory of the user whose apps are managed
+func (m *Manager) homeDir() string {
+	if m.asUser != nil {
+		return m.asUser.HomeDir
+	}
+	home, _ := os.UserHomeDir()
+	return home
+}
+
+// stateDir returns the pm2go state directory of the managed user
+func (m *Manager) stateDir() string {
+	if m.asUser != nil {
+		return filepath.Join(m.asUser.HomeDir, ".pm2go")
+	}
+	return config.Dir()
+}
+
+// LogDir returns the directory of the default log files
+func (m *Manager) LogDir() string {
+	return filepath.Join(m.homeDir(), ".pm2", "logs")
+}
+
+// mkdirAll creates a directory like os.MkdirAll. When root manages another
+// user's apps, the directories inside that user's home are handed over so
+// their service manager can use them.
+func (m *Manager) mkdirAll(dir string, perm os.FileMode) error {
+	if m.DryRun() {
+		return nil
+	}
+	if err := os.MkdirAll(dir, perm); err != nil {
+		return err
+	}
+	if m.asUser == nil {
+		return nil
+	}
+	for path := dir; strings.HasPrefix(path, m.asUser.HomeDir+"/"); path = filepath.Dir(path) {
+		if err := m.chown(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFile writes a file like os.WriteFile, owned by the managed user
+func (m *Manager) writeFile(path string, data []byte, perm os.FileMode) error {
+	if m.DryRun() {
+		m.dryRunf("Would write %s\n", path)
+		return nil
+	}
+	if err := os.WriteFile(path, data, perm); err != nil {
+		return err
+	}
+	return m.chown(path)
+}
+
+// chown hands a file over to the managed user when it is another user
+func (m *Manager) chown(path string) error {
+	if m.asUser == nil {
+		return nil
+	}
+	uid, _ := strconv.Atoi(m.asUser.Uid)
+	gid, _ := strconv.Atoi(m.asUser.Gid)
+	if err := os.Chown(path, uid, gid); err != nil {
+		return fmt.Errorf("failed to change owner of %s: %v", path, err)
+	}
+	return nil
+}
@@ -53,12 +53,12 @@ func (m *Manager) GetStartupCommands() []string {
 	}
 
 	username := m.getCurrentUser()
-	uid := os.Getuid()
+	uid := m.uid()
 
 	return []string{
 		fmt.Sprintf("sudo loginctl enable-linger %s", username),
 		fmt.Sprintf("sudo systemctl enable user@%d.service", uid),
-		"systemctl --user daemon-reload",
+		"systemctl " + strings.Join(m.scopeArgs(), " ") + " daemon-reload",
 	}
 }
 
@@ -90,7 +90,7 @@ func (m *Manager) enableUserSystemdService() error {
 		return nil // Not needed for system services
 	}
 
-	uid := os.Getuid()
+	uid := m.uid()
 	serviceName := fmt.Sprintf("user@%d.service", uid)
 
 	// Check if already enabled
@@ -115,8 +115,7 @@ func (m *Manager) reloadUserSystemd() error {
 		return nil // Not needed for system services
 	}
 
-	cmd := exec.Command("systemctl", "--user", "daemon-reload")
-	return cmd.Run()
+	return m.systemctl("daemon-reload").Run()
 }
 
 // checkLingering checks if user lingering is enabled
@@ -131,7 +130,7 @@ func (m *Manager) checkLingering() string {
 
 // checkUserService checks if user@UID.service is enabled
 func (m *Manager) checkUserService() string {
-	uid := os.Getuid()
+	uid := m.uid()
 	cmd := exec.Command("systemctl", "is-enabled", fmt.Sprintf("user@%d.service", uid))
 	output, err := cmd.Output()
 	if err != nil {
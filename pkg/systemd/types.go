@@ -1,5 +1,7 @@
 package systemd
 
+import "github.com/wojtekw92/pm2go/pkg/logs"
+
 // AppConfig represents the configuration for a PM2 application
 type AppConfig struct {
 	ID          int               `json:"id"`
@@ -7,10 +9,83 @@ type AppConfig struct {
 	Script      string            `json:"script"`
 	Interpreter string            `json:"interpreter,omitempty"`
 	Cwd         string            `json:"cwd,omitempty"`
-	Args        string            `json:"args,omitempty"`
+	Args        []string          `json:"args,omitempty"`
 	Env         map[string]string `json:"env,omitempty"`
+	OutFile     string            `json:"out_file,omitempty"`
+	ErrorFile   string            `json:"error_file,omitempty"`
+	LogFile     string            `json:"log_file,omitempty"`
+	MergeLogs   bool              `json:"merge_logs,omitempty"`
+	Instances   int               `json:"instances,omitempty"`
+	LogBackend  string            `json:"log_backend,omitempty"`
+	EnvFiles    []string          `json:"env_file,omitempty"` // dotenv files merged into env
+	InheritEnv  string            `json:"inherit_env,omitempty"` // shell variables to inherit: all, none or a list
+	Sandbox     string            `json:"sandbox,omitempty"`     // hardening preset: none, basic or strict
+	SandboxSet  map[string]string `json:"sandbox_override,omitempty"` // directives replacing the preset's ("" removes one)
+	User        string            `json:"user,omitempty"`  // system mode: account the app runs as (name or uid)
+	Group       string            `json:"group,omitempty"` // system mode: group the app runs as (name or gid)
+	Systemd     SystemdSections   `json:"systemd,omitempty"` // extra unit directives by section: unit, service, install
+	Template    string            `json:"unit_template,omitempty"` // text/template the unit file is rendered from
+
+	EnvProfiles map[string]map[string]string `json:"-"` // env_<profile> blocks
+	EnvProfile  string                       `json:"-"` // active env profile
+	Ecosystem   string                       `json:"-"` // ecosystem file the app was started from
+	EnvSources  map[string]string            `json:"-"` // where each env variable came from
+}
+
+// SystemdSections holds raw unit directives by lower-case section name
+// (unit, service or install)
+type SystemdSections map[string]map[string]string
+
+// Log backends an app can write its output to
+const (
+	LogBackendFile    = "file"    // PM2-style log files
+	LogBackendJournal = "journal" // systemd journal
+)
+
+// Keys used to record pm2go metadata in service files
+const (
+	metaEnvProfile = "X-PM2Go-EnvProfile"
+	metaEcosystem  = "X-PM2Go-Ecosystem"
+	metaEnvFile    = "X-PM2Go-EnvFile"
+	metaEnvSource  = "X-PM2Go-EnvSource"
+	metaInheritEnv = "X-PM2Go-InheritEnv"
+	metaSandbox    = "X-PM2Go-Sandbox"
+	metaSandboxSet = "X-PM2Go-SandboxOverride"
+	metaTemplate   = "X-PM2Go-UnitTemplate"
+	metaLogFile    = "X-PM2Go-LogFile"
+	metaUser       = "X-PM2Go-User"
+	metaScript     = "X-PM2Go-Script"
+	metaInterp     = "X-PM2Go-Interpreter"
+	metaArgs       = "X-PM2Go-Args"
+	metaGroup      = "X-PM2Go-Group"
+)
+
+// Sources of environment variables, shown by "pm2go env"
+const (
+	EnvSourceShell     = "shell"     // inherited from the shell that ran pm2go start
+	EnvSourceCLI       = "cli"       // set with --env KEY=VALUE
+	EnvSourceEcosystem = "ecosystem" // env block of an ecosystem file
+)
+
+// Shell environment inheritance policies; any other value is a comma
+// separated list of the variables to inherit
+const (
+	InheritEnvAll  = "all"
+	InheritEnvNone = "none"
+)
+
+// EnvFileSource returns the source label of variables read from an env file
+func EnvFileSource(path string) string {
+	return "env_file:" + path
 }
 
+// NullLogPath disables a log stream when used as out_file, error_file or log_file
+const NullLogPath = "/dev/null"
+
+// PMIDPlaceholder stands for the process ID in ecosystem values when the ID
+// is only known once the app is started
+const PMIDPlaceholder = "${pm_id}"
+
 // EcosystemConfig represents PM2 ecosystem file structure
 type EcosystemConfig struct {
 	Apps []AppConfig `json:"apps"`
@@ -38,9 +113,21 @@ type PM2Env struct {
 	PMExecPath       string            `json:"pm_exec_path"`
 	PMOutLogPath     string            `json:"pm_out_log_path"`
 	PMErrLogPath     string            `json:"pm_err_log_path"`
+	PMLogPath        string            `json:"pm_log_path,omitempty"`
+	LogBackend       string            `json:"log_backend,omitempty"`
+	Cwd              string            `json:"pm_cwd,omitempty"`
+	EnvProfile       string            `json:"env_profile,omitempty"`
+	EcosystemFile    string            `json:"ecosystem_file,omitempty"`
+	InheritEnv       string            `json:"inherit_env,omitempty"`
+	Sandbox          string            `json:"sandbox,omitempty"`
+	Username         string            `json:"username,omitempty"`
+	Group            string            `json:"group,omitempty"`
+	Scope            string            `json:"scope"`
+	DropIns          []string          `json:"drop_ins,omitempty"`
+	UnitTemplate     string            `json:"unit_template,omitempty"`
 	PMPidPath        string            `json:"pm_pid_path"`
 	Interpreter      string            `json:"interpreter"`
-	Args             string            `json:"args"`
+	Args             []string          `json:"args"`
 	Env              map[string]string `json:"env"`
 }
 
@@ -57,9 +144,36 @@ type PM2Monit struct {
 type ServiceConfig struct {
 	Script      string
 	Interpreter string
-	Args        string
+	Args        []string
+	ExecInterp  string // interpreter in ExecStart=, also one picked by the script's extension
 	OutLogPath  string
 	ErrLogPath  string
+	LogPath     string // set when both streams share one file
+	LogFile     string // log_file given with out_file/error_file, not written
+	LogBackend  string
+	Cwd         string
+	EnvProfile  string
+	Ecosystem   string
 	PidPath     string
 	Env         map[string]string
-}
\ No newline at end of file
+	EnvFiles    []string
+	EnvSources  map[string]string
+	InheritEnv  string
+	Sandbox     string
+	SandboxSet  map[string]string
+	User        string
+	Group       string
+	AppUser     string // user given for the app, User= may be the default
+	AppGroup    string // group given for the app
+	Systemd     SystemdSections
+	Template    string
+}
+
+// LogOptions controls how application logs are read and displayed
+type LogOptions struct {
+	Lines  int         // number of recent lines per log file (0 = all)
+	Follow bool        // keep streaming new lines
+	Raw    bool        // print messages without the app prefix
+	JSON   bool        // print one JSON object per line
+	Filter logs.Filter // stream, pattern and time window selection
+}
@@ -0,0 +1,143 @@
+package systemd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// DefaultUnitTemplate lays out the unit file of an app unless a custom
+// template replaces it. User-mode units leave out User=, which the user
+// service manager can't switch to.
+const DefaultUnitTemplate = `[Unit]
+Description=PM2 App: {{.Name}}
+After=network.target
+
+[Service]
+Type=simple
+{{- if not .UserMode}}
+User={{.ServiceUser}}
+{{- if .Group}}
+Group={{.Group}}
+{{- end}}
+{{- end}}
+WorkingDirectory={{.WorkingDirectory}}
+ExecStart={{.ExecStart}}
+Restart=always
+RestartSec=3
+StandardOutput={{.StandardOutput}}
+{{- if .SyslogIdentifier}}
+SyslogIdentifier={{.SyslogIdentifier}}
+{{- end}}
+StandardError={{.StandardError}}
+
+[Install]
+WantedBy=default.target
+`
+
+// UnitData is what unit templates are rendered with: the app's
+// configuration plus the values pm2go derives from it
+type UnitData struct {
+	AppConfig
+	ServiceName      string // unit name without .service
+	UserMode         bool   // rendered for the user service manager
+	ServiceUser      string // User= in system mode
+	WorkingDirectory string
+	ExecStart        string
+	StandardOutput   string // StandardOutput= value (append:<file>, journal or null)
+	StandardError    string
+	SyslogIdentifier string // set when the app logs to the journal
+}
+
+// unitTemplatePath returns the template an app's unit is rendered from:
+// the app's own, else ~/.pm2go/unit.tmpl when it exists. "" means the
+// built-in template.
+func (m *Manager) unitTemplatePath(config AppConfig) string {
+	if config.Template != "" {
+		return config.Template
+	}
+	global := filepath.Join(m.stateDir(), "unit.tmpl")
+	if _, err := os.Stat(global); err == nil {
+		return global
+	}
+	return ""
+}
+
+// UnitTemplateSource describes where the unit of an app is rendered from
+func (m *Manager) UnitTemplateSource(config AppConfig) string {
+	if path := m.unitTemplatePath(config); path != "" {
+		return path
+	}
+	return "built-in"
+}
+
+// RenderUnit returns the unit file an app would be installed with
+func (m *Manager) RenderUnit(config AppConfig) (string, error) {
+	return m.generateServiceFile(config)
+}
+
+// loadUnitTemplate parses a unit template file, or the built-in template
+// when path is empty
+func loadUnitTemplate(path string) (*template.Template, error) {
+	if path == "" {
+		return template.New("unit").Option("missingkey=error").Parse(DefaultUnitTemplate)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read unit template: %v", err)
+	}
+	tmpl, err := template.New(filepath.Base(path)).Option("missingkey=error").Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid unit template: %v", err)
+	}
+	return tmpl, nil
+}
+
+// ValidateUnitTemplate checks that a unit template file can be parsed
+func ValidateUnitTemplate(path string) error {
+	_, err := loadUnitTemplate(path)
+	return err
+}
+
+// renderUnit renders the unit template of an app
+func (m *Manager) renderUnit(path string, data UnitData) (string, error) {
+	tmpl, err := loadUnitTemplate(path)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render unit template: %v", err)
+	}
+	return b.String(), nil
+}
+
+// appendToSection adds lines to the end of a unit file section, before the
+// blank lines that separate it from the next one. A missing section is
+// added at the end.
+func appendToSection(unit, section, lines string) string {
+	if unit != "" && !strings.HasSuffix(unit, "\n") {
+		unit += "\n"
+	}
+
+	header := "[" + section + "]"
+	start := -1
+	if strings.HasPrefix(unit, header+"\n") {
+		start = 0
+	} else if i := strings.Index(unit, "\n"+header+"\n"); i >= 0 {
+		start = i + 1
+	}
+	if start < 0 {
+		return unit + "\n" + header + "\n" + lines
+	}
+
+	// The section ends where the next one starts
+	end := len(unit)
+	if i := strings.Index(unit[start+len(header):], "\n["); i >= 0 {
+		end = start + len(header) + i + 1
+	}
+	body := strings.TrimRight(unit[start:end], "\n")
+	return unit[:start] + body + "\n" + lines + unit[start+len(body)+1:]
+}
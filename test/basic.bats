@@ -57,6 +57,24 @@ teardown() {
     [[ "$output" == *"test-custom"* ]]
 }
 
+@test "pm2go restart --update-env keeps the interpreter flags, script and args" {
+    mkdir -p "$BATS_TMPDIR/my scripts"
+    cp test/fixtures/test-app.py "$BATS_TMPDIR/my scripts/app.py"
+    
+    run ./pm2go start "python3 -u" --name test-argv -- "$BATS_TMPDIR/my scripts/app.py" --interval 1 'a b'
+    [[ "$status" -eq 0 ]]
+    run ./pm2go restart test-argv --update-env
+    [[ "$status" -eq 0 ]]
+    
+    run ./pm2go ecosystem generate
+    [[ "$output" == *'"script": "'"$BATS_TMPDIR"'/my scripts/app.py"'* ]]
+    [[ "$output" == *'"interpreter": "python3 -u"'* ]]
+    [[ "$output" == *'"a b"'* ]]
+    
+    run ./pm2go describe test-argv
+    [[ "$output" == *"interpreter"*"python3 -u"* ]]
+}
+
 @test "pm2go can stop a process by name" {
     # Start a process
     run ./pm2go start test/fixtures/test-app.py --name test-stop
@@ -104,4 +122,156 @@ teardown() {
     run ./pm2go stop non-existent
     [[ "$status" -eq 1 ]]
     [[ "$output" == *"not found"* ]]
-}
\ No newline at end of file
+}
+
+@test "pm2go start --uid and --gid run the app as another account" {
+    if [[ "$(id -u)" -ne 0 ]]; then
+        skip "needs system mode"
+    fi
+    
+    run ./pm2go start test/fixtures/test-app.py --name test-uid --uid nobody --gid daemon
+    [[ "$status" -eq 0 ]]
+    
+    run ./pm2go describe test-uid
+    [[ "$output" == *"user"*"nobody"* ]]
+    [[ "$output" == *"group"*"daemon"* ]]
+    [[ "$(stat -c %U:%G ~/.pm2/logs/test-uid-out.log)" == "nobody:daemon" ]]
+    
+    # Unknown accounts are rejected
+    run ./pm2go start test/fixtures/test-app.py --name test-uid-bad --uid no-such-user
+    [[ "$status" -ne 0 ]]
+    [[ "$output" == *"does not exist"* ]]
+}
+
+@test "pm2go keeps an explicit --uid equal to the current user" {
+    if [[ "$(id -u)" -ne 0 ]]; then
+        skip "needs system mode"
+    fi
+    
+    run ./pm2go start test/fixtures/test-app.py --name test-uid-self --uid "$(id -un)"
+    [[ "$status" -eq 0 ]]
+    run ./pm2go start test/fixtures/test-app.py --name test-uid-default
+    [[ "$status" -eq 0 ]]
+    
+    run ./pm2go ecosystem generate
+    [[ "$status" -eq 0 ]]
+    [[ "$(echo "$output" | grep -c '"user"')" -eq 1 ]]
+    [[ "$output" == *'"user": "'"$(id -un)"'"'* ]]
+}
+
+@test "pm2go list marks the scope of each app" {
+    run ./pm2go start test/fixtures/test-app.py --name test-scope
+    [[ "$status" -eq 0 ]]
+    
+    expected="user"
+    if [[ "$(id -u)" -eq 0 ]]; then
+        expected="system"
+    fi
+    run ./pm2go list
+    [[ "$output" == *"scope"* ]]
+    [[ "$output" == *"test-scope"*"$expected"* ]]
+    
+    # Conflicting scope flags are rejected
+    run ./pm2go --user --system list
+    [[ "$status" -ne 0 ]]
+    [[ "$output" == *"can't be combined"* ]]
+}
+
+@test "pm2go --dry-run prints units without installing them" {
+    run ./pm2go start test/fixtures/test-app.py --name dry-app --dry-run
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"Would write "*"pm2-"*"-dry-app.service:"* ]]
+    [[ "$output" == *"ExecStart="*"test-app.py"* ]]
+    [[ "$output" == *"Would run: systemctl"*"start pm2-"*"-dry-app"* ]]
+    [[ "$output" == *"(dry run) Started dry-app"* ]]
+    
+    run ./pm2go list
+    [[ "$output" != *"dry-app"* ]]
+    
+    run ./pm2go start test/fixtures/test-app.py --name dry-app
+    [[ "$status" -eq 0 ]]
+    
+    run ./pm2go delete dry-app --dry-run
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"Would remove "*"-dry-app.service"* ]]
+    
+    run ./pm2go list
+    [[ "$output" == *"dry-app"* ]]
+}
+
+@test "pm2go --dry-run leaves logs and output files alone" {
+    run ./pm2go start test/fixtures/test-app.py --name dry-files
+    [[ "$status" -eq 0 ]]
+    echo "kept" >> ~/.pm2/logs/dry-files-out.log
+    
+    run ./pm2go flush dry-files --dry-run
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"Would truncate "*"dry-files-out.log"* ]]
+    grep -q "kept" ~/.pm2/logs/dry-files-out.log
+    
+    run ./pm2go export dry-files --output "$BATS_TMPDIR/dry-export" --dry-run
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"Would write $BATS_TMPDIR/dry-export/dry-files.service"* ]]
+    [[ ! -e "$BATS_TMPDIR/dry-export" ]]
+    
+    run ./pm2go ecosystem generate -o "$BATS_TMPDIR/dry-ecosystem.json" --dry-run
+    [[ "$status" -eq 0 ]]
+    [[ ! -e "$BATS_TMPDIR/dry-ecosystem.json" ]]
+    
+    run ./pm2go ecosystem init "$BATS_TMPDIR/dry-ecosystem.yml" --dry-run
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"Would write $BATS_TMPDIR/dry-ecosystem.yml"* ]]
+    [[ ! -e "$BATS_TMPDIR/dry-ecosystem.yml" ]]
+}
+
+@test "pm2go --dry-run doesn't report actions as done" {
+    run ./pm2go start test/fixtures/test-app.py --name dry-done
+    [[ "$status" -eq 0 ]]
+    
+    run ./pm2go restart dry-done --dry-run
+    [[ "$output" == *"(dry run) Restarted dry-done"* ]]
+    [[ "$output" != *"✓"* ]]
+    
+    run ./pm2go stop dry-done --dry-run
+    [[ "$output" == *"(dry run) Stopped dry-done"* ]]
+    run ./pm2go list
+    [[ "$output" == *"dry-done"*"online"* ]]
+    
+    run ./pm2go override dry-done --set service.LimitNOFILE=4096 --dry-run
+    [[ "$output" == *"(dry run) Updated drop-in"* ]]
+    run ./pm2go describe dry-done
+    [[ "$output" != *"override.conf"* ]]
+    
+    # A new drop-in edited under --dry-run is reported as written
+    EDITOR="sed -i 's/^# \[Service\]/[Service]/; s/^# LimitNOFILE/LimitNOFILE/'" run ./pm2go override dry-done --dry-run
+    [[ "$output" == *"(dry run) Wrote drop-in"* ]]
+}
+
+@test "pm2go run returns the exit code of a job and records it" {
+    run ./pm2go run --name exit-job -- sh -c 'echo job-output; exit 3'
+    [[ "$status" -eq 3 ]]
+    [[ "$output" == *"job-output"* ]]
+    
+    run ./pm2go jobs
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"exit-job"*"failed"*"3"* ]]
+    
+    # Jobs leave no unit behind
+    run ./pm2go list
+    [[ "$output" != *"exit-job"* ]]
+    
+    run ./pm2go run --name ok-job -- true
+    [[ "$status" -eq 0 ]]
+    run ./pm2go jobs --limit 1
+    [[ "$output" == *"ok-job"*"succeeded"* ]]
+    [[ "$output" != *"exit-job"* ]]
+}
+
+@test "pm2go list shows running jobs with a j-prefixed ID" {
+    ./pm2go run --name list-job -- sleep 3 >/dev/null 2>&1 &
+    sleep 1
+    
+    run ./pm2go list
+    [[ "$output" =~ j[0-9]+[[:space:]]*│[[:space:]]*list-job ]]
+    wait
+}
@@ -55,6 +55,10 @@ teardown() {
     [[ "$output" == *"another-value"* ]]
     [[ "$output" == *"APP_ID"* ]]
     [[ "$output" == *"2"* ]]
+    
+    # env_file fills in variables without overriding env
+    [[ "$output" == *"DOTENV_PLAIN: plain-value  [env_file:"* ]]
+    [[ "$output" == *"TEST_ENV: another-value  [ecosystem]"* ]]
 }
 
 @test "ecosystem apps use custom interpreters and arguments" {
@@ -144,4 +148,340 @@ teardown() {
     [[ "$status" -eq 0 ]]
     [[ "$output" != *"test-app-1"* ]]
     [[ "$output" != *"test-app-2"* ]]
-}
\ No newline at end of file
+}
+
+@test "pm2go can start from a YAML ecosystem file" {
+    run ./pm2go start test/fixtures/test-ecosystem.yml
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"Started test-yaml-app-1"* ]]
+    [[ "$output" == *"Started test-yaml-app-2"* ]]
+    
+    sleep 2
+    
+    # Environment comes through the same schema as JSON
+    run ./pm2go env test-yaml-app-1
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"yaml-value"* ]]
+}
+
+@test "pm2go can start from ecosystem.config.js" {
+    run ./pm2go start test/fixtures/ecosystem.config.js
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"Started test-js-app"* ]]
+    
+    sleep 2
+    
+    run ./pm2go env test-js-app
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"js-value"* ]]
+}
+
+@test "pm2go applies ecosystem env profiles" {
+    run ./pm2go start test/fixtures/test-ecosystem.json --env production
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"Started test-app-1"* ]]
+    [[ "$output" == *"test-app-2 has no env_production block"* ]]
+    
+    sleep 2
+    
+    # Profile values override env, the rest is kept
+    run ./pm2go env test-app-1
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"production-value"* ]]
+    [[ "$output" == *"APP_ID"* ]]
+    
+    run ./pm2go describe test-app-1
+    [[ "$output" == *"env profile"*"production"* ]]
+    
+    # Unknown profiles are rejected
+    run ./pm2go start test/fixtures/test-ecosystem.json --env staging
+    [[ "$status" -ne 0 ]]
+    [[ "$output" == *"env profile 'staging' is not defined"* ]]
+}
+
+@test "pm2go apply reconciles an ecosystem file" {
+    # Dry run only prints the plan
+    run ./pm2go apply test/fixtures/test-ecosystem.json --dry-run
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"+ test-app-1 (create)"* ]]
+    [[ "$output" == *"Plan: 2 to create"* ]]
+    
+    run ./pm2go list
+    [[ "$output" != *"test-app-1"* ]]
+    
+    run ./pm2go apply test/fixtures/test-ecosystem.json
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"Created test-app-1"* ]]
+    [[ "$output" == *"Created test-app-2"* ]]
+    
+    sleep 2
+    
+    # Applying again is a no-op instead of a duplicate name error
+    run ./pm2go apply test/fixtures/test-ecosystem.json
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"= test-app-1 (unchanged)"* ]]
+    
+    # Switching the env profile only touches apps that define it
+    run ./pm2go apply test/fixtures/test-ecosystem.json --env production --dry-run
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"~ test-app-1 (update)"* ]]
+    [[ "$output" == *"= test-app-2 (unchanged)"* ]]
+}
+
+@test "pm2go startOrRestart and --only act on selected ecosystem apps" {
+    run ./pm2go start test/fixtures/test-ecosystem.json --only test-app-1
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"Started test-app-1"* ]]
+    [[ "$output" != *"test-app-2"* ]]
+    
+    sleep 2
+    
+    # Running apps are restarted, missing ones started
+    run ./pm2go startOrRestart test/fixtures/test-ecosystem.json
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"Restarted test-app-1"* ]]
+    [[ "$output" == *"Started test-app-2"* ]]
+    
+    run ./pm2go stop test/fixtures/test-ecosystem.json --only test-app-2
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"Stopped test-app-2"* ]]
+    [[ "$output" != *"test-app-1"* ]]
+    
+    # Unknown names are rejected
+    run ./pm2go startOrReload test/fixtures/test-ecosystem.json --only missing-app
+    [[ "$status" -ne 0 ]]
+    [[ "$output" == *"app 'missing-app' is not defined"* ]]
+}
+
+@test "pm2go ecosystem validate reports issues with locations" {
+    run ./pm2go ecosystem validate test/fixtures/test-ecosystem.json
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"is valid"* ]]
+    
+    run ./pm2go ecosystem validate test/fixtures/invalid-ecosystem.json
+    [[ "$status" -ne 0 ]]
+    [[ "$output" == *"\$.apps[0].scirpt_args: unknown key"* ]]
+    [[ "$output" == *"\$.apps[0].instances: expected an integer"* ]]
+    [[ "$output" == *"\$.apps[0].script: script"*"not found"* ]]
+    [[ "$output" == *"\$.apps[1].cwd"* ]]
+    [[ "$output" == *"\$.apps[1].interpreter"* ]]
+    [[ "$output" == *"duplicate name 'test-bad-app'"* ]]
+    
+    # Invalid files are not partially started
+    run ./pm2go start test/fixtures/invalid-ecosystem.json
+    [[ "$status" -ne 0 ]]
+    [[ "$output" == *"nothing was started"* ]]
+    
+    run ./pm2go list
+    [[ "$output" != *"test-bad-app"* ]]
+}
+
+@test "pm2go ecosystem generate codifies running apps" {
+    run ./pm2go start test/fixtures/test-app.py --name test-generated --env GENERATED_VAR=codified
+    [[ "$status" -eq 0 ]]
+    
+    run ./pm2go ecosystem generate -o "$BATS_TMPDIR/generated-ecosystem.yml"
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"Wrote 1 apps"* ]]
+    
+    run cat "$BATS_TMPDIR/generated-ecosystem.yml"
+    [[ "$output" == *"name: test-generated"* ]]
+    [[ "$output" == *"GENERATED_VAR: codified"* ]]
+    [[ "$output" != *"HOME:"* ]]
+    
+    # Variables are told apart by where they came from, not by their value
+    NODE_ENV=production PM2GO_SHELL_ONLY=old run ./pm2go start test/fixtures/test-app.py --name test-provenance -e NODE_ENV=production
+    [[ "$status" -eq 0 ]]
+    NODE_ENV=production PM2GO_SHELL_ONLY=new run ./pm2go ecosystem generate
+    [[ "$output" == *'"NODE_ENV": "production"'* ]]
+    [[ "$output" != *"PM2GO_SHELL_ONLY"* ]]
+    
+    # The generated file is a valid ecosystem file
+    run ./pm2go ecosystem validate "$BATS_TMPDIR/generated-ecosystem.yml"
+    [[ "$status" -eq 0 ]]
+    
+    rm -f "$BATS_TMPDIR/generated-ecosystem.yml"
+}
+
+@test "pm2go ecosystem init writes a sample" {
+    rm -f "$BATS_TMPDIR/ecosystem.config.js"
+    
+    run ./pm2go ecosystem init "$BATS_TMPDIR/ecosystem.config.js"
+    [[ "$status" -eq 0 ]]
+    
+    run grep -c "//" "$BATS_TMPDIR/ecosystem.config.js"
+    [[ "$output" -gt 0 ]]
+    
+    # Existing files are not overwritten
+    run ./pm2go ecosystem init "$BATS_TMPDIR/ecosystem.config.js"
+    [[ "$status" -ne 0 ]]
+    [[ "$output" == *"already exists"* ]]
+    
+    rm -f "$BATS_TMPDIR/ecosystem.config.js"
+}
+
+@test "pm2go interpolates variables in ecosystem files" {
+    # Missing variables are reported before anything starts
+    run ./pm2go ecosystem validate test/fixtures/interpolated-ecosystem.yml
+    [[ "$status" -ne 0 ]]
+    [[ "$output" == *"variable 'GREETING' is not set"* ]]
+    
+    APP_SUFFIX=web run ./pm2go start test/fixtures/interpolated-ecosystem.yml --var-file test/fixtures/test-vars.env
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"Started test-interp-web"* ]]
+    
+    sleep 2
+    
+    run ./pm2go env test-interp-web
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"from-var-file"* ]]
+    [[ "$output" == *"test-interp-web-0"* ]]
+    
+    run ./pm2go logs test-interp-web --nostream --raw
+    [[ "$output" == *"hello from var file"* ]]
+}
+
+@test "pm2go can start a Procfile" {
+    run ./pm2go start test/fixtures/procfile-app/Procfile --formation web=2,worker=1
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"Started procfile-app-web-0"* ]]
+    [[ "$output" == *"Started procfile-app-web-1"* ]]
+    [[ "$output" == *"Started procfile-app-worker"* ]]
+    
+    sleep 2
+    
+    # Variables come from the sibling .env file and reach the shell
+    run ./pm2go env procfile-app-web-0
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"procfile-value"* ]]
+    
+    run ./pm2go logs procfile-app-web-0 --nostream --raw
+    [[ "$output" == *"web listening on 5000"* ]]
+    
+    # Unknown process types are rejected
+    run ./pm2go start test/fixtures/procfile-app/Procfile --formation db=1
+    [[ "$status" -ne 0 ]]
+    [[ "$output" == *"process type 'db' is not defined"* ]]
+}
+
+@test "ecosystem apps inherit shell variables only when asked to" {
+    INHERIT_TEST_VAR=from-shell run ./pm2go start test/fixtures/test-ecosystem.json --only test-app-1
+    [[ "$status" -eq 0 ]]
+    
+    run ./pm2go env test-app-1
+    [[ "$output" != *"INHERIT_TEST_VAR"* ]]
+    
+    INHERIT_TEST_VAR=from-shell TEST_ENV=shell-value run ./pm2go restart test-app-1 --inherit-env INHERIT_TEST_VAR,TEST_ENV
+    [[ "$status" -eq 0 ]]
+    
+    # Inherited variables don't override the ecosystem env
+    run ./pm2go env test-app-1
+    [[ "$output" == *"INHERIT_TEST_VAR: from-shell  [shell]"* ]]
+    [[ "$output" == *"TEST_ENV: ecosystem-value  [ecosystem]"* ]]
+}
+
+@test "ecosystem systemd sections are kept across regeneration" {
+    run ./pm2go start test/fixtures/test-ecosystem.json --only test-app-2
+    [[ "$status" -eq 0 ]]
+    
+    run ./pm2go ecosystem generate
+    [[ "$output" == *'"LimitNOFILE": "4096"'* ]]
+    
+    # Unknown sections are rejected by the validator
+    echo '{"apps":[{"name":"bad","script":"test-app.py","systemd":{"timer":{"OnCalendar":"daily"}}}]}' > "$BATS_TMPDIR/systemd-ecosystem.json"
+    run ./pm2go ecosystem validate "$BATS_TMPDIR/systemd-ecosystem.json"
+    [[ "$status" -ne 0 ]]
+    [[ "$output" == *"unknown systemd section 'timer'"* ]]
+    rm -f "$BATS_TMPDIR/systemd-ecosystem.json"
+}
+
+@test "ecosystem args keep quoted words and special characters" {
+    run ./pm2go start test/fixtures/test-ecosystem.json --only test-app-1
+    [[ "$status" -eq 0 ]]
+    
+    run ./pm2go describe test-app-1
+    [[ "$output" == *"--message 'App 1 output'"* ]]
+    
+    run ./pm2go start --name quoted python3 -- test/fixtures/test-app.py --message 'cost: 50% of $5'
+    [[ "$status" -eq 0 ]]
+    
+    run ./pm2go ecosystem generate
+    [[ "$output" == *'"App 1 output"'* ]]
+    [[ "$output" == *'"cost: 50% of $5"'* ]]
+}
+
+@test "other *.config.js scripts are started, not loaded as ecosystem files" {
+    local dir="$BATS_TMPDIR/pm2go-config-js"
+    mkdir -p "$dir"
+    echo "module.exports = { entry: './index.js' }" > "$dir/webpack.config.js"
+    
+    run ./pm2go start "$dir/webpack.config.js" --dry-run
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"(dry run) Started webpack.config"* ]]
+    
+    # Config files that define apps are ecosystem files
+    echo "module.exports = { apps: [{ name: 'pm2-config-app', script: '$PWD/test/fixtures/test-app.py' }] }" > "$dir/pm2.config.js"
+    run ./pm2go start "$dir/pm2.config.js" --dry-run
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"pm2-config-app"* ]]
+    rm -rf "$dir"
+}
+
+@test "pm2go apply from another directory keeps apps without a cwd unchanged" {
+    local dir="$BATS_TMPDIR/pm2go-nocwd"
+    mkdir -p "$dir"
+    cp test/fixtures/test-app.py "$dir/"
+    echo '{"apps":[{"name":"test-nocwd","script":"test-app.py","args":"--interval 1"}]}' > "$dir/ecosystem.json"
+    
+    run ./pm2go apply "$dir/ecosystem.json"
+    [[ "$status" -eq 0 ]]
+    run ./pm2go describe test-nocwd
+    [[ "$output" == *"$dir"* ]]
+    
+    sleep 2
+    local pm2go="$PWD/pm2go"
+    run bash -c "cd / && '$pm2go' apply '$dir/ecosystem.json' --dry-run"
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"= test-nocwd (unchanged)"* ]]
+    rm -rf "$dir"
+}
+
+@test "ecosystem stop tells apps that were never started from stopped ones" {
+    run ./pm2go start test/fixtures/test-ecosystem.json --only test-app-1
+    [[ "$status" -eq 0 ]]
+    
+    run ./pm2go stop test/fixtures/test-ecosystem.json
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"Stopped test-app-1"* ]]
+    [[ "$output" == *"- test-app-2 not found, skipped"* ]]
+    
+    run ./pm2go stop test/fixtures/test-ecosystem.json
+    [[ "$output" == *"- test-app-1 is not running (stopped), skipped"* ]]
+}
+
+@test "relative cwd is resolved from the ecosystem file's directory" {
+    local dir="$BATS_TMPDIR/cwd-ecosystem"
+    mkdir -p "$dir/app"
+    cp test/fixtures/test-app.py "$dir/app/"
+    cat > "$dir/ecosystem.json" <<JSON
+{"apps": [{"name": "test-relcwd", "script": "test-app.py", "interpreter": "python3", "cwd": "app"}]}
+JSON
+    
+    local pm2go="$PWD/pm2go"
+    mkdir -p "$BATS_TMPDIR/caller"
+    run bash -c "cd '$BATS_TMPDIR/caller' && '$pm2go' ecosystem validate '$dir/ecosystem.json'"
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"is valid"* ]]
+    
+    run bash -c "cd '$BATS_TMPDIR/caller' && '$pm2go' start '$dir/ecosystem.json'"
+    [[ "$status" -eq 0 ]]
+    run ./pm2go describe test-relcwd
+    [[ "$output" == *"$dir/app"* ]]
+    
+    # A directory of the same name in the current directory is warned about
+    mkdir "$BATS_TMPDIR/caller/app"
+    run bash -c "cd '$BATS_TMPDIR/caller' && '$pm2go' ecosystem validate '$dir/ecosystem.json'"
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"\$.apps[0].cwd: resolved from the ecosystem file's directory"* ]]
+    rm -rf "$dir" "$BATS_TMPDIR/caller"
+}
@@ -0,0 +1,15 @@
+module.exports = {
+  apps: [
+    {
+      name: 'test-js-app',
+      script: 'test-app.py',
+      interpreter: 'python3',
+      cwd: __dirname,
+      args: '--interval 1 --max-count 10',
+      env: {
+        TEST_ENV: process.env.TEST_JS_VALUE || 'js-value',
+        APP_ID: '1',
+      },
+    },
+  ],
+};
@@ -148,4 +148,139 @@ teardown() {
     [[ "$output" == *"interpreter"* ]]
     [[ "$output" == *"script path"* ]]
     [[ "$output" == *"script args"* ]]
-}
\ No newline at end of file
+}
+
+@test "pm2go security shows sandbox directives" {
+    run ./pm2go start test/fixtures/test-app.py --name test-sandbox --sandbox strict --sandbox-set ProtectHome=no --sandbox-set RestrictAddressFamilies=
+    [[ "$status" -eq 0 ]]
+    
+    run ./pm2go security test-sandbox --directives
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"Sandbox of test-sandbox: strict"* ]]
+    [[ "$output" == *"NoNewPrivileges"* ]]
+    [[ "$output" == *"ProtectHome"*"no"*"override"* ]]
+    [[ "$output" == *"ReadWritePaths"* ]]
+    [[ "$output" != *"RestrictAddressFamilies"* ]]
+    
+    run ./pm2go describe test-sandbox
+    [[ "$output" == *"sandbox"*"strict"* ]]
+    
+    # Unknown presets are rejected
+    run ./pm2go start test/fixtures/test-app.py --name test-sandbox-bad --sandbox paranoid
+    [[ "$status" -ne 0 ]]
+    [[ "$output" == *"unknown sandbox"* ]]
+}
+
+@test "pm2go override writes drop-ins that describe lists" {
+    run ./pm2go start test/fixtures/test-app.py --name test-override
+    [[ "$status" -eq 0 ]]
+    
+    run ./pm2go override test-override --set service.LimitNOFILE=4096
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"Updated drop-in"* ]]
+    
+    run ./pm2go describe test-override
+    [[ "$output" == *"drop-ins"*"pm2-"*"-test-override.service.d/override.conf"* ]]
+    
+    # Drop-ins survive regenerating the unit
+    run ./pm2go restart test-override --update-env
+    [[ "$status" -eq 0 ]]
+    run ./pm2go describe test-override
+    [[ "$output" == *"override.conf"* ]]
+    
+    run ./pm2go override test-override --set LimitNOFILE=4096
+    [[ "$status" -ne 0 ]]
+    [[ "$output" == *"section.Name=value"* ]]
+    
+    run ./pm2go override test-override --remove
+    [[ "$status" -eq 0 ]]
+    run ./pm2go describe test-override
+    [[ "$output" == *"drop-ins"*"none"* ]]
+}
+
+@test "pm2go renders units from custom templates" {
+    run ./pm2go unit template
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"ExecStart={{.ExecStart}}"* ]]
+    
+    printf '[Unit]\nDescription=Custom {{.Name}}\n\n[Service]\nWorkingDirectory={{.WorkingDirectory}}\nExecStart={{.ExecStart}}\nStandardOutput={{.StandardOutput}}\nStandardError={{.StandardError}}\n' > "$BATS_TMPDIR/unit.tmpl"
+    run ./pm2go start test/fixtures/test-app.py --name test-template --unit-template "$BATS_TMPDIR/unit.tmpl"
+    [[ "$status" -eq 0 ]]
+    
+    run ./pm2go unit render test-template
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"Description=Custom test-template"* ]]
+    [[ "$output" != *"Restart=always"* ]]
+    
+    run ./pm2go describe test-template
+    [[ "$output" == *"unit template"*"unit.tmpl"* ]]
+    
+    # Templates that don't render are reported
+    echo '{{.NoSuchField}}' > "$BATS_TMPDIR/bad-unit.tmpl"
+    run ./pm2go unit render test-template --template "$BATS_TMPDIR/bad-unit.tmpl"
+    [[ "$status" -ne 0 ]]
+    [[ "$output" == *"NoSuchField"* ]]
+    rm -f "$BATS_TMPDIR/unit.tmpl" "$BATS_TMPDIR/bad-unit.tmpl"
+}
+
+@test "pm2go export writes standalone artifacts" {
+    run ./pm2go start --name export-app -e EXPORT_VAR=value python3 -- test/fixtures/test-app.py --message 'App 1 output'
+    [[ "$status" -eq 0 ]]
+    
+    local dir="$BATS_TMPDIR/pm2go-export"
+    rm -rf "$dir"
+    
+    run ./pm2go export export-app --format systemd -o "$dir"
+    [[ "$status" -eq 0 ]]
+    [[ -f "$dir/export-app.service" ]]
+    grep -q '^ExecStart=.*--message "App 1 output"' "$dir/export-app.service"
+    grep -q "^EnvironmentFile=$dir/export-app.env" "$dir/export-app.service"
+    ! grep -q "X-PM2Go" "$dir/export-app.service"
+    grep -q 'EXPORT_VAR="value"' "$dir/export-app.env"
+    
+    run ./pm2go export export-app --format docker-compose -o "$dir"
+    [[ "$status" -eq 0 ]]
+    grep -q "export-app:" "$dir/docker-compose.yml"
+    
+    run ./pm2go export export-app --format procfile -o "$dir"
+    [[ "$status" -eq 0 ]]
+    grep -q "^export-app: .*--message 'App 1 output'" "$dir/Procfile"
+    
+    run ./pm2go export export-app --format tarball -o "$dir"
+    [[ "$status" -ne 0 ]]
+    [[ "$output" == *"unsupported format"* ]]
+    rm -rf "$dir"
+}
+
+@test "pm2go export procfile keeps trailing newlines of multi-line values" {
+    local dir="$BATS_TMPDIR/export-pem"
+    mkdir -p "$dir"
+    # Values only one app sets are exported inline rather than in .env
+    cat > "$dir/ecosystem.json" <<JSON
+{"apps": [
+  {"name": "export-pem", "script": "$PWD/test/fixtures/test-app.py", "env": {"PEM": "line1\\nline2\\n"}},
+  {"name": "export-plain", "script": "$PWD/test/fixtures/test-app.py"}
+]}
+JSON
+    run ./pm2go start "$dir/ecosystem.json"
+    [[ "$status" -eq 0 ]]
+    
+    run ./pm2go export all --format procfile -o "$dir/out"
+    [[ "$status" -eq 0 ]]
+    
+    local entry
+    entry="$(sed -n 's/^export-pem: \(.*\) && exec .*/\1/p' "$dir/out/Procfile")"
+    run sh -c "$entry && printf '%s|' \"\$PEM\""
+    [[ "$output" == $'line1\nline2\n|' ]]
+    rm -rf "$dir"
+}
+
+@test "pm2go security resolves paths from the app's directory, not the current one" {
+    run ./pm2go start test/fixtures/test-app.py --name test-sandbox-cwd --sandbox strict
+    [[ "$status" -eq 0 ]]
+    
+    local pm2go="$PWD/pm2go"
+    run bash -c "cd / && '$pm2go' security test-sandbox-cwd --directives"
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"ReadWritePaths"*"-$PWD "* ]]
+}
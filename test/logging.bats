@@ -143,4 +143,178 @@ teardown() {
     # Flush all logs
     run ./pm2go flush
     [[ "$status" -eq 0 ]]
-}
\ No newline at end of file
+}
+
+@test "pm2go logs can filter by stream and pattern" {
+    # Start a process that writes to both stdout and stderr
+    run ./pm2go start python3 --name test-log-filter -- test/fixtures/test-app.py --max-count 4 --interval 1 --error-every 2
+    [[ "$status" -eq 0 ]]
+    
+    # Wait for output including errors
+    sleep 5
+    
+    # Only stderr
+    run ./pm2go logs test-log-filter --err --raw --nostream
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"ERROR"* ]]
+    [[ "$output" != *"Hello from PM2go test app"* ]]
+    
+    # Only lines matching a pattern
+    run ./pm2go logs test-log-filter --grep '#2:' --raw --nostream
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"#2:"* ]]
+    [[ "$output" != *"#1:"* ]]
+}
+
+@test "pm2go logs supports JSON output and time windows" {
+    # Start a process with timestamped output
+    run ./pm2go start python3 --name test-log-json -- test/fixtures/test-app.py --max-count 2 --interval 1
+    [[ "$status" -eq 0 ]]
+    
+    # Wait for output
+    sleep 3
+    
+    # JSON output includes the parsed timestamp
+    run ./pm2go logs test-log-json --json --nostream
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *'"app":"test-log-json"'* ]]
+    [[ "$output" == *'"timestamp":'* ]]
+    
+    # A window in the future matches nothing
+    run ./pm2go logs test-log-json --raw --since 2099-01-01 --nostream
+    [[ "$status" -eq 0 ]]
+    [[ "$output" != *"Hello from PM2go test app"* ]]
+    
+    # Invalid time specs are rejected
+    run ./pm2go logs test-log-json --since yesterday-ish
+    [[ "$status" -eq 1 ]]
+}
+
+@test "pm2go writes logs to custom paths" {
+    local logdir="$BATS_TMPDIR/pm2go-custom-logs"
+    rm -rf "$logdir"
+    
+    # Start with a combined log file
+    run ./pm2go start python3 --name test-custom-log --log "$logdir/combined.log" -- test/fixtures/test-app.py --max-count 2 --interval 1 --error-every 1
+    [[ "$status" -eq 0 ]]
+    
+    sleep 3
+    
+    # Both streams end up in the combined file
+    [[ -f "$logdir/combined.log" ]]
+    grep -q "Hello from PM2go test app" "$logdir/combined.log"
+    grep -q "ERROR" "$logdir/combined.log"
+    
+    # describe reports the real path
+    run ./pm2go describe test-custom-log
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"$logdir/combined.log"* ]]
+    
+    # A combined log can't be split by stream
+    run ./pm2go logs test-custom-log --out --nostream
+    [[ "$status" -ne 0 ]]
+    [[ "$output" == *"can't tell them apart"* ]]
+}
+
+@test "pm2go keeps log_file next to out_file and error_file" {
+    local logdir="$BATS_TMPDIR/pm2go-split-logs"
+    rm -rf "$logdir"
+    
+    run ./pm2go start python3 --name test-split-log --output "$logdir/out.log" --error "$logdir/err.log" --log "$logdir/combined.log" -- test/fixtures/test-app.py --max-count 2 --interval 1
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"log_file is not written"* ]]
+    
+    # The split files are written and log_file round-trips
+    sleep 2
+    [[ -f "$logdir/out.log" ]]
+    run ./pm2go ecosystem generate
+    [[ "$output" == *'"log_file": "'"$logdir/combined.log"'"'* ]]
+}
+
+@test "pm2go can disable a log stream with /dev/null" {
+    run ./pm2go start python3 --name test-null-log --error /dev/null -- test/fixtures/test-app.py --max-count 2 --interval 1 --error-every 1
+    [[ "$status" -eq 0 ]]
+    
+    sleep 3
+    
+    run ./pm2go jlist
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *'"pm_err_log_path": "/dev/null"'* ]]
+    
+    # Only stdout is shown
+    run ./pm2go logs test-null-log --raw --nostream
+    [[ "$status" -eq 0 ]]
+    [[ "$output" != *"ERROR"* ]]
+}
+
+@test "pm2go can log to the journal" {
+    run ./pm2go start python3 --name test-journal --log-backend journal -- test/fixtures/test-app.py --max-count 2 --interval 1 --error-every 1
+    [[ "$status" -eq 0 ]]
+    
+    sleep 3
+    
+    run ./pm2go describe test-journal
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"journal"* ]]
+    
+    run ./pm2go logs test-journal --raw --nostream
+    [[ "$status" -eq 0 ]]
+    [[ "$output" == *"Hello from PM2go test app"* ]]
+    [[ "$output" == *"ERROR #1: This is an error message"* ]]
+    
+    # The journal can't tell the streams apart
+    run ./pm2go logs test-journal --err --nostream
+    [[ "$status" -ne 0 ]]
+    [[ "$output" == *"--out and --err can't be used"* ]]
+    
+    # Flushed entries are no longer shown
+    run ./pm2go flush test-journal
+    [[ "$status" -eq 0 ]]
+    run ./pm2go logs test-journal --raw --nostream
+    [[ "$status" -eq 0 ]]
+    [[ "$output" != *"Hello from PM2go test app"* ]]
+}
+
+@test "pm2go forward ships logs to a TCP listener" {
+    local received="$BATS_TMPDIR/pm2go-forward.ndjson"
+    rm -f "$received" "$received.port"
+    
+    # Local newline-delimited JSON listener on a free port
+    python3 -c '
+import socket, sys
+srv = socket.socket()
+srv.bind(("127.0.0.1", 0))
+srv.listen(1)
+with open(sys.argv[1] + ".port", "w") as port:
+    port.write(str(srv.getsockname()[1]))
+conn, _ = srv.accept()
+with open(sys.argv[1], "wb") as out:
+    while True:
+        data = conn.recv(4096)
+        if not data:
+            break
+        out.write(data)
+        out.flush()
+' "$received" &
+    local listener=$!
+    for _ in 1 2 3 4 5 6 7 8 9 10; do
+        [[ -s "$received.port" ]] && break
+        sleep 0.5
+    done
+    local port
+    port=$(cat "$received.port")
+    
+    run ./pm2go start python3 --name test-forward -- test/fixtures/test-app.py --interval 1
+    [[ "$status" -eq 0 ]]
+    
+    ./pm2go forward test-forward --to "tcp://127.0.0.1:$port" --flush-interval 200ms &
+    local forwarder=$!
+    
+    sleep 4
+    kill -INT "$forwarder"
+    wait "$forwarder" || true
+    kill "$listener" 2>/dev/null || true
+    
+    grep -q '"app":"test-forward"' "$received"
+    grep -q "Hello from PM2go test app" "$received"
+}